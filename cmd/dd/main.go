@@ -0,0 +1,345 @@
+// Command dd converts JSON into Go literals dumped by dd.
+//
+// The JSON is read from the files or the standard input if no files are specified.
+// The files can be specified as glob patterns.
+//
+//	$ curl -s https://api.example.com/users/1 | dd
+//	map[string]interface {}{
+//	  "id":   1.0,
+//	  "name": "codehex",
+//	}
+//
+// The whole Go source file is generated if -var or -package flag is specified.
+//
+//	$ dd -package fixtures -var user -number int64 user.json
+//
+// The struct types are inferred from the JSON and declared in the file if -infer flag is specified.
+//
+//	$ dd -package fixtures -var user -infer User user.json
+//
+// The JSON is dumped as the literal of the existing type if -type flag is specified.
+// The type is resolved in the module of the current directory, and the module
+// must require github.com/Code-Hex/dd.
+//
+//	$ dd -package fixtures -var user -type example.com/app/users.User user.json
+//
+// The CSV is dumped if -csv flag is specified. The header row is declared as
+// the struct type with -infer flag, otherwise it is used as the map keys.
+//
+//	$ dd -csv -var users -infer User users.csv
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Code-Hex/dd"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "err: %q\n", err.Error())
+		os.Exit(1)
+	}
+}
+
+// number formats of the JSON numbers.
+const (
+	numberFloat64 = "float64"
+	numberJSON    = "json"
+	numberInt64   = "int64"
+)
+
+type config struct {
+	indent       int
+	exportedOnly bool
+	uintFormat   string
+	varName      string
+	pkgName      string
+	number       string
+	inferType    string
+	typeName     string
+	csv          bool
+	csvHeader    bool
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	var cfg config
+	fs := flag.NewFlagSet("dd", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: dd [flags] [files or glob patterns...]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.IntVar(&cfg.indent, "indent", 2, "the number of spaces for indentation")
+	fs.BoolVar(&cfg.exportedOnly, "exported-only", false, "dump only exported struct fields")
+	fs.StringVar(&cfg.uintFormat, "uint-format", "decimal", "the format of unsigned integers: decimal, binary or hex")
+	fs.StringVar(&cfg.varName, "var", "", "generate the Go source file which declares the variable with this name")
+	fs.StringVar(&cfg.pkgName, "package", "", "generate the Go source file with this package name")
+	fs.StringVar(&cfg.number, "number", numberFloat64, "decode JSON numbers as float64, json (json.Number) or int64 (int64 if integral)")
+	fs.StringVar(&cfg.typeName, "type", "", "unmarshal JSON into this type qualified by the import path like example.com/pkg.Type (-number is not applied)")
+	fs.StringVar(&cfg.inferType, "infer", "", "infer the struct types from JSON and declare them in the Go source file with this root type name")
+	fs.BoolVar(&cfg.csv, "csv", false, "read the inputs as CSV instead of JSON")
+	fs.BoolVar(&cfg.csvHeader, "csv-header", true, "use the first row of CSV as the header. if false, CSV is dumped as [][]string")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	opts, err := cfg.options()
+	if err != nil {
+		return err
+	}
+	inputs, err := expandInputs(fs.Args())
+	if err != nil {
+		return err
+	}
+	fileMode := cfg.varName != "" || cfg.pkgName != "" || cfg.inferType != "" || cfg.csv
+	if fileMode && len(inputs) > 1 {
+		return fmt.Errorf("only one input is allowed to generate the Go source file, but got %d inputs", len(inputs))
+	}
+
+	if cfg.typeName != "" {
+		return runTyped(&cfg, inputs, fileMode, stdin, stdout)
+	}
+	if cfg.csv {
+		return runCSV(&cfg, inputs[0], opts, stdin, stdout)
+	}
+
+	for i, input := range inputs {
+		if cfg.inferType != "" {
+			content, err := readInput(input, stdin)
+			if err != nil {
+				return err
+			}
+			src, err := dd.DumpJSONFile(cfg.packageName(), cfg.variableName(), cfg.inferType, content, opts...)
+			if err != nil {
+				return fmt.Errorf("failed to dump %q: %w", input, err)
+			}
+			_, err = stdout.Write(src)
+			return err
+		}
+		v, err := decodeInput(input, stdin, cfg.number)
+		if err != nil {
+			return err
+		}
+		if fileMode {
+			src, err := dd.DumpFile(cfg.packageName(), cfg.variableName(), v, opts...)
+			if err != nil {
+				return err
+			}
+			_, err = stdout.Write(src)
+			return err
+		}
+		writeInputHeader(stdout, inputs, i)
+		if _, err := fmt.Fprintln(stdout, dd.Dump(v, opts...)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeInputHeader writes the name of the i'th input if there are multiple inputs.
+func writeInputHeader(w io.Writer, inputs []string, i int) {
+	if len(inputs) == 1 {
+		return
+	}
+	if i > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "// %s\n", inputs[i])
+}
+
+func runCSV(cfg *config, input string, opts []dd.OptionFunc, stdin io.Reader, stdout io.Writer) error {
+	content, err := readInput(input, stdin)
+	if err != nil {
+		return err
+	}
+	var src []byte
+	if cfg.csvHeader {
+		src, err = dd.DumpCSVFile(cfg.packageName(), cfg.variableName(), cfg.inferType, content, opts...)
+	} else {
+		src, err = dd.DumpCSVRecordsFile(cfg.packageName(), cfg.variableName(), content, opts...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to dump %q: %w", input, err)
+	}
+	_, err = stdout.Write(src)
+	return err
+}
+
+func runTyped(cfg *config, inputs []string, fileMode bool, stdin io.Reader, stdout io.Writer) error {
+	if cfg.inferType != "" {
+		return errors.New("-type and -infer can not be used together")
+	}
+	if cfg.csv {
+		return errors.New("-type and -csv can not be used together")
+	}
+	td, err := newTypedDumper(cfg, fileMode)
+	if err != nil {
+		return err
+	}
+	defer td.Close()
+	for i, input := range inputs {
+		content, err := readInput(input, stdin)
+		if err != nil {
+			return err
+		}
+		writeInputHeader(stdout, inputs, i)
+		if err := td.Dump(stdout, content); err != nil {
+			return fmt.Errorf("failed to dump %q: %w", input, err)
+		}
+	}
+	return nil
+}
+
+func (c *config) options() ([]dd.OptionFunc, error) {
+	opts := []dd.OptionFunc{dd.WithIndent(c.indent)}
+	if c.exportedOnly {
+		opts = append(opts, dd.WithExportedOnly())
+	}
+	switch c.uintFormat {
+	case "decimal":
+		opts = append(opts, dd.WithUintFormat(dd.DecimalUint))
+	case "binary":
+		opts = append(opts, dd.WithUintFormat(dd.BinaryUint))
+	case "hex":
+		opts = append(opts, dd.WithUintFormat(dd.HexUint))
+	default:
+		return nil, fmt.Errorf("unknown uint format %q", c.uintFormat)
+	}
+	switch c.number {
+	case numberFloat64, numberJSON, numberInt64:
+	default:
+		return nil, fmt.Errorf("unknown number format %q", c.number)
+	}
+	return opts, nil
+}
+
+// uintFormats is the names of the uint formats.
+var uintFormats = map[string]string{
+	"decimal": "dd.DecimalUint",
+	"binary":  "dd.BinaryUint",
+	"hex":     "dd.HexUint",
+}
+
+// optionExprs returns the options as Go expressions.
+func (c *config) optionExprs() []string {
+	exprs := []string{fmt.Sprintf("dd.WithIndent(%d)", c.indent)}
+	if c.exportedOnly {
+		exprs = append(exprs, "dd.WithExportedOnly()")
+	}
+	return append(exprs, fmt.Sprintf("dd.WithUintFormat(%s)", uintFormats[c.uintFormat]))
+}
+
+func (c *config) packageName() string {
+	if c.pkgName == "" {
+		return "main"
+	}
+	return c.pkgName
+}
+
+func (c *config) variableName() string {
+	if c.varName == "" {
+		return "data"
+	}
+	return c.varName
+}
+
+// stdinName is the name of the input which means the standard input.
+const stdinName = "-"
+
+// expandInputs expands the glob patterns in args.
+func expandInputs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{stdinName}, nil
+	}
+	var inputs []string
+	for _, arg := range args {
+		if !strings.ContainsAny(arg, "*?[") {
+			inputs = append(inputs, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %q", arg)
+		}
+		inputs = append(inputs, matches...)
+	}
+	return inputs, nil
+}
+
+func readInput(input string, stdin io.Reader) ([]byte, error) {
+	if input == stdinName {
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return content, nil
+	}
+	content, err := os.ReadFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %q: %w", input, err)
+	}
+	return content, nil
+}
+
+func decodeInput(input string, stdin io.Reader, number string) (interface{}, error) {
+	content, err := readInput(input, stdin)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(content))
+	if number != numberFloat64 {
+		dec.UseNumber()
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %q: %w", input, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("failed to unmarshal %q: unexpected data after the JSON value", input)
+	}
+	if number == numberInt64 {
+		v = convertNumbers(v)
+	}
+	return v, nil
+}
+
+// convertNumbers converts json.Number in v to int64 if it is integral,
+// otherwise float64.
+func convertNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		// e.g. 1e3
+		if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+			return int64(f)
+		}
+		return f
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = convertNumbers(elem)
+		}
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = convertNumbers(elem)
+		}
+	}
+	return v
+}
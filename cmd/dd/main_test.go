@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a.json": `{"id": 1}`,
+		"b.json": `[true, null]`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{
+			name:  "stdin",
+			stdin: `{"name": "codehex", "tags": ["a"]}`,
+			want: `map[string]interface {}{
+  "name": "codehex",
+  "tags": []interface {}{
+    "a",
+  },
+}
+`,
+		},
+		{
+			name:  "indent",
+			args:  []string{"--indent", "4"},
+			stdin: `[1]`,
+			want: `[]interface {}{
+    1.0,
+}
+`,
+		},
+		{
+			name:  "json number",
+			args:  []string{"--number", "json"},
+			stdin: `[1, 1.5]`,
+			want: `[]interface {}{
+  json.Number("1"),
+  json.Number("1.5"),
+}
+`,
+		},
+		{
+			name:  "int64 number",
+			args:  []string{"--number", "int64"},
+			stdin: `[1, 1e3, 1.5]`,
+			want: `[]interface {}{
+  int64(1),
+  int64(1000),
+  1.5,
+}
+`,
+		},
+		{
+			name: "glob",
+			args: []string{filepath.Join(dir, "*.json")},
+			want: `// ` + filepath.Join(dir, "a.json") + `
+map[string]interface {}{
+  "id": 1.0,
+}
+
+// ` + filepath.Join(dir, "b.json") + `
+[]interface {}{
+  true,
+  nil,
+}
+`,
+		},
+		{
+			name:  "file",
+			args:  []string{"--package", "fixtures", "--var", "user", "--number", "json"},
+			stdin: `{"id": 1}`,
+			want: `package fixtures
+
+import "encoding/json"
+
+var user = map[string]interface{}{
+	"id": json.Number("1"),
+}
+`,
+		},
+		{
+			name:  "infer",
+			args:  []string{"--var", "user", "--infer", "User"},
+			stdin: `{"id": 1, "tags": [{"name": "a"}]}`,
+			want: `package main
+
+type User struct {
+	ID   int64 ` + "`" + `json:"id"` + "`" + `
+	Tags []Tag ` + "`" + `json:"tags"` + "`" + `
+}
+
+type Tag struct {
+	Name string ` + "`" + `json:"name"` + "`" + `
+}
+
+var user = User{
+	ID: 1,
+	Tags: []Tag{
+		Tag{
+			Name: "a",
+		},
+	},
+}
+`,
+		},
+		{
+			name:  "csv",
+			args:  []string{"--csv", "--infer", "Row"},
+			stdin: "id,name\n1,a\n",
+			want: `package main
+
+type Row struct {
+	ID   int64  ` + "`" + `csv:"id"` + "`" + `
+	Name string ` + "`" + `csv:"name"` + "`" + `
+}
+
+var data = []Row{
+	Row{
+		ID:   1,
+		Name: "a",
+	},
+}
+`,
+		},
+		{
+			name:  "csv without header",
+			args:  []string{"--csv", "--csv-header=false"},
+			stdin: "1,a\n2,b\n",
+			want: `package main
+
+var data = [][]string{
+	[]string{
+		"1", "a",
+	},
+	[]string{
+		"2", "b",
+	},
+}
+`,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := run(tc.args, strings.NewReader(tc.stdin), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunError(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{
+			name: "unknown uint format",
+			args: []string{"--uint-format", "octal"},
+			want: `unknown uint format "octal"`,
+		},
+		{
+			name: "no matches",
+			args: []string{"testdata/*.json"},
+			want: `no files match "testdata/*.json"`,
+		},
+		{
+			name:  "trailing data",
+			stdin: `{} {}`,
+			want:  "unexpected data after the JSON value",
+		},
+		{
+			name: "unqualified type",
+			args: []string{"--type", "Point"},
+			want: "the type must be qualified by the import path",
+		},
+		{
+			name: "multiple inputs for file",
+			args: []string{"--var", "v", "a.json", "b.json"},
+			want: "only one input is allowed",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := run(tc.args, strings.NewReader(tc.stdin), &bytes.Buffer{})
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("want the error contains %q, but got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+func TestRunType(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the test which builds the program in short mode")
+	}
+	cases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "dump",
+			args: []string{"--type", "image.Point"},
+			want: `image.Point{
+  X: 1,
+  Y: 2,
+}
+`,
+		},
+		{
+			name: "file",
+			args: []string{"--type", "image.Point", "--var", "point"},
+			want: `package main
+
+import "image"
+
+var point = image.Point{
+	X: 1,
+	Y: 2,
+}
+`,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := run(tc.args, strings.NewReader(`{"X": 1, "Y": 2}`), &buf); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, buf.String()); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
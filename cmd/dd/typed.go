@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"text/template"
+)
+
+// typedProgram is the program which unmarshals JSON from stdin into the type
+// and dumps it. It is built in the current module to import the type.
+var typedProgram = template.Must(template.New("program").Parse(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Code-Hex/dd"
+	target {{ printf "%q" .PkgPath }}
+)
+
+func main() {
+	var v target.{{ .TypeName }}
+	if err := json.NewDecoder(os.Stdin).Decode(&v); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to unmarshal into {{ .TypeName }}: %v\n", err)
+		os.Exit(1)
+	}
+	opts := []dd.OptionFunc{
+		{{- range .Options }}
+		{{ . }},
+		{{- end }}
+	}
+	{{- if .FileMode }}
+	src, err := dd.DumpFile({{ printf "%q" .PkgName }}, {{ printf "%q" .VarName }}, v, opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(src)
+	{{- else }}
+	fmt.Println(dd.Dump(v, opts...))
+	{{- end }}
+}
+`))
+
+// splitTypeName splits the type name qualified by the import path.
+// e.g. example.com/app/orders.Item
+func splitTypeName(s string) (pkgPath, typeName string, err error) {
+	dot := strings.LastIndexByte(s, '.')
+	if dot <= strings.LastIndexByte(s, '/') || dot == len(s)-1 {
+		return "", "", fmt.Errorf("invalid type %q: the type must be qualified by the import path like example.com/pkg.Type", s)
+	}
+	return s[:dot], s[dot+1:], nil
+}
+
+// typedDumper dumps JSON as the literal of the type using the program
+// built in the current module.
+type typedDumper struct {
+	dir  string
+	prog string
+	// signals receives the interrupts while the directory exists.
+	signals chan os.Signal
+	done    chan struct{}
+	// ctx is canceled by the interrupts to kill the running command.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mu is held while the command is running not to remove the directory
+	// before the command exits.
+	mu sync.Mutex
+}
+
+func newTypedDumper(cfg *config, fileMode bool) (*typedDumper, error) {
+	pkgPath, typeName, err := splitTypeName(cfg.typeName)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = typedProgram.Execute(&buf, map[string]interface{}{
+		"PkgPath":  pkgPath,
+		"TypeName": typeName,
+		"Options":  cfg.optionExprs(),
+		"FileMode": fileMode,
+		"PkgName":  cfg.packageName(),
+		"VarName":  cfg.variableName(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the program: %w", err)
+	}
+
+	// the directory is created in the current directory to build the program
+	// in the current module which can import the type.
+	dir, err := os.MkdirTemp(".", "dd-type-")
+	if err != nil {
+		return nil, err
+	}
+	prog := "dd-type"
+	if runtime.GOOS == "windows" {
+		prog += ".exe"
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	td := &typedDumper{
+		dir:     dir,
+		prog:    filepath.Join(dir, prog),
+		signals: make(chan os.Signal, 1),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	// the directory is left in the user's module if dd is interrupted
+	// without removing it.
+	signal.Notify(td.signals, os.Interrupt, syscall.SIGTERM)
+	go td.removeOnSignal()
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), src, 0o644); err != nil {
+		td.Close()
+		return nil, err
+	}
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", td.prog, "./"+filepath.ToSlash(dir))
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := td.run(cmd); err != nil {
+		td.Close()
+		return nil, fmt.Errorf("failed to build the program for %s: %w\n%s", cfg.typeName, err, out.String())
+	}
+	return td, nil
+}
+
+// Dump writes the dumped JSON in content to w.
+func (td *typedDumper) Dump(w io.Writer, content []byte) error {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(td.ctx, td.prog)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := td.run(cmd); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func (td *typedDumper) run(cmd *exec.Cmd) error {
+	td.mu.Lock()
+	defer td.mu.Unlock()
+	return cmd.Run()
+}
+
+// removeOnSignal removes the directory after the running command is killed
+// and exits if dd is interrupted. The command may not receive the signal
+// unless it is sent to the process group.
+func (td *typedDumper) removeOnSignal() {
+	select {
+	case <-td.signals:
+		td.cancel()
+		td.mu.Lock()
+		os.RemoveAll(td.dir)
+		os.Exit(1)
+	case <-td.done:
+	}
+}
+
+// Close removes the program.
+func (td *typedDumper) Close() error {
+	signal.Stop(td.signals)
+	close(td.done)
+	td.cancel()
+	return os.RemoveAll(td.dir)
+}
@@ -80,6 +80,25 @@ func TestComplex(t *testing.T) {
 	}
 }
 
+func TestComplexUndump(t *testing.T) {
+	entries, err := makeEntries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		entry := entry
+		t.Run(entry.name, func(t *testing.T) {
+			var got interface{}
+			if err := dd.Undump(string(entry.want), &got); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(entry.value, got); diff != "" {
+				t.Fatalf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
 // 2022-03-20
 // goos: darwin
 // goarch: arm64
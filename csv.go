@@ -0,0 +1,253 @@
+package dd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// DumpCSVFile dumps the CSV records as the Go source file which declares
+// the variable named varName in package pkgName.
+//
+// The first record is the header. If typeName is not empty, the struct type
+// named typeName is declared from the header and the records are dumped as
+// []typeName. Otherwise, the records are dumped as []map[string]interface{}
+// keyed by the header.
+//
+// The type of each column is inferred from the values: int64, float64, bool,
+// time.Time (RFC 3339) or string. The empty values in the column whose type
+// is not string are dumped as nil. If the records have the different number
+// of fields, the records are dumped as [][]string and the fields of each record
+// are written in a line by WithListBreakLineSize.
+func DumpCSVFile(pkgName, varName, typeName string, data []byte, opts ...OptionFunc) ([]byte, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("failed to read CSV: the header is not found")
+	}
+	width := len(records[0])
+	for _, record := range records {
+		if len(record) != width {
+			return dumpCSVStrings(pkgName, varName, records, opts)
+		}
+	}
+
+	header, rows := records[0], records[1:]
+	columns := make([]csvColumn, width)
+	for i := range columns {
+		values := make([]string, len(rows))
+		for j, row := range rows {
+			values[j] = row[i]
+		}
+		columns[i] = inferColumn(values)
+	}
+
+	var (
+		value     reflect.Value
+		typeNames map[reflect.Type]string
+		typ       reflect.Type
+	)
+	if typeName != "" {
+		typ, value = csvStructs(header, columns, rows)
+		typeNames = map[reflect.Type]string{typ: typeName}
+	} else {
+		value = csvMaps(header, columns, rows)
+	}
+
+	d := newDataDumper(nil, value.Interface(), opts...)
+	d.initImports(varName, typeName)
+	d.typeNames = typeNames
+	if _, ok := d.convertibleTypes[timeType]; !ok {
+		d.convertibleTypes[timeType] = d.dumpRFC3339Time
+	}
+	var decls []string
+	if typ != nil {
+		decls = d.typeDecls(typ)
+	}
+	return d.dumpFile(pkgName, varName, decls)
+}
+
+// DumpCSVRecordsFile dumps the CSV records without the header as [][]string
+// in the Go source file which declares the variable named varName in package
+// pkgName. The fields of each record are written in a line.
+func DumpCSVRecordsFile(pkgName, varName string, data []byte, opts ...OptionFunc) ([]byte, error) {
+	records, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	return dumpCSVStrings(pkgName, varName, records, opts)
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return records, nil
+}
+
+// dumpCSVStrings dumps the records as [][]string.
+func dumpCSVStrings(pkgName, varName string, records [][]string, opts []OptionFunc) ([]byte, error) {
+	width := 1
+	for _, record := range records {
+		if len(record) > width {
+			width = len(record)
+		}
+	}
+	opts = append([]OptionFunc{WithListBreakLineSize("", width)}, opts...)
+	return DumpFile(pkgName, varName, records, opts...)
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// csvColumn is the inferred type of the column.
+type csvColumn struct {
+	typ   reflect.Type
+	parse func(string) (interface{}, error)
+	// optional reports whether the column has the empty values.
+	optional bool
+}
+
+// csvColumnTypes is the types of the columns in order of priority.
+var csvColumnTypes = []csvColumn{
+	{
+		typ: reflect.TypeOf(int64(0)),
+		parse: func(s string) (interface{}, error) {
+			return strconv.ParseInt(s, 10, 64)
+		},
+	},
+	{
+		typ: reflect.TypeOf(float64(0)),
+		parse: func(s string) (interface{}, error) {
+			f, err := strconv.ParseFloat(s, 64)
+			// ParseFloat also accepts "NaN", "Inf" and "Infinity".
+			if err == nil && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				return nil, fmt.Errorf("%q is not a finite number", s)
+			}
+			return f, err
+		},
+	},
+	{
+		typ: reflect.TypeOf(false),
+		parse: func(s string) (interface{}, error) {
+			return strconv.ParseBool(s)
+		},
+	},
+	{
+		typ: timeType,
+		parse: func(s string) (interface{}, error) {
+			return time.Parse(time.RFC3339, s)
+		},
+	},
+}
+
+// inferColumn returns the first type in csvColumnTypes which can parse all
+// non-empty values. If there is no such type, it returns string.
+func inferColumn(values []string) csvColumn {
+	optional, empty := false, true
+	for _, v := range values {
+		if v == "" {
+			optional = true
+		} else {
+			empty = false
+		}
+	}
+	if !empty {
+	next:
+		for _, col := range csvColumnTypes {
+			for _, v := range values {
+				if v == "" {
+					continue
+				}
+				if _, err := col.parse(v); err != nil {
+					continue next
+				}
+			}
+			col.optional = optional
+			return col
+		}
+	}
+	return csvColumn{
+		typ: reflect.TypeOf(""),
+		parse: func(s string) (interface{}, error) {
+			return s, nil
+		},
+	}
+}
+
+// csvStructs returns the struct type of the header and the slice of the records.
+func csvStructs(header []string, columns []csvColumn, rows [][]string) (reflect.Type, reflect.Value) {
+	fields := make([]reflect.StructField, len(header))
+	usedFields := make(map[string]bool)
+	for i, name := range header {
+		fieldName := uniqueName(usedFields, fieldNameOf(name))
+		usedFields[fieldName] = true
+		typ := columns[i].typ
+		if columns[i].optional {
+			typ = reflect.PtrTo(typ)
+		}
+		fields[i] = reflect.StructField{
+			Name: fieldName,
+			Type: typ,
+			Tag:  reflect.StructTag("csv:" + strconv.Quote(name)),
+		}
+	}
+	typ := reflect.StructOf(fields)
+	ret := reflect.MakeSlice(reflect.SliceOf(typ), len(rows), len(rows))
+	for i, row := range rows {
+		elem := ret.Index(i)
+		for j, s := range row {
+			if s == "" && columns[j].optional {
+				continue
+			}
+			v, _ := columns[j].parse(s)
+			field := elem.Field(j)
+			if columns[j].optional {
+				ptr := reflect.New(columns[j].typ)
+				ptr.Elem().Set(reflect.ValueOf(v))
+				field.Set(ptr)
+				continue
+			}
+			field.Set(reflect.ValueOf(v))
+		}
+	}
+	return typ, ret
+}
+
+// csvMaps returns the records as the maps keyed by the header.
+func csvMaps(header []string, columns []csvColumn, rows [][]string) reflect.Value {
+	ret := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		m := make(map[string]interface{}, len(row))
+		for j, s := range row {
+			if s == "" && columns[j].optional {
+				m[header[j]] = nil
+				continue
+			}
+			m[header[j]], _ = columns[j].parse(s)
+		}
+		ret[i] = m
+	}
+	return reflect.ValueOf(ret)
+}
+
+// dumpRFC3339Time dumps time.Time as the parsed RFC 3339 string.
+// The time package is qualified by the name in the imports.
+func (d *dumper) dumpRFC3339Time(rv reflect.Value, w Writer) {
+	t := rv.Interface().(time.Time)
+	pkg := d.qualify("time", "time")
+	if pkg != "" {
+		pkg += "."
+	}
+	w.Write(fmt.Sprintf("func() %sTime ", pkg))
+	w.WriteBlock(fmt.Sprintf("tmp, _ := %sParse(%sRFC3339, %q)\nreturn tmp", pkg, pkg, t.Format(time.RFC3339Nano)))
+	w.Write("()")
+}
@@ -0,0 +1,184 @@
+package dd_test
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDumpCSVFile(t *testing.T) {
+	const data = `id,name,score,active,created_at
+1,a,1.5,true,2022-03-06T12:00:00Z
+2,b,2,false,
+`
+	cases := []struct {
+		name     string
+		typeName string
+		data     string
+		opts     []dd.OptionFunc
+		want     string
+	}{
+		{
+			name:     "struct",
+			typeName: "Row",
+			data:     data,
+			want: `package fixtures
+
+import "time"
+
+type Row struct {
+	ID        int64      ` + "`" + `csv:"id"` + "`" + `
+	Name      string     ` + "`" + `csv:"name"` + "`" + `
+	Score     float64    ` + "`" + `csv:"score"` + "`" + `
+	Active    bool       ` + "`" + `csv:"active"` + "`" + `
+	CreatedAt *time.Time ` + "`" + `csv:"created_at"` + "`" + `
+}
+
+var rows = []Row{
+	Row{
+		ID:     1,
+		Name:   "a",
+		Score:  1.5,
+		Active: true,
+		CreatedAt: func() *time.Time {
+			v := func() time.Time {
+				tmp, _ := time.Parse(time.RFC3339, "2022-03-06T12:00:00Z")
+				return tmp
+			}()
+			return &v
+		}(),
+	},
+	Row{
+		ID:        2,
+		Name:      "b",
+		Score:     2.0,
+		Active:    false,
+		CreatedAt: (*time.Time)(nil),
+	},
+}
+`,
+		},
+		{
+			name: "map",
+			data: data,
+			want: `package fixtures
+
+import "time"
+
+var rows = []map[string]interface{}{
+	map[string]interface{}{
+		"active": true,
+		"created_at": func() time.Time {
+			tmp, _ := time.Parse(time.RFC3339, "2022-03-06T12:00:00Z")
+			return tmp
+		}(),
+		"id":    int64(1),
+		"name":  "a",
+		"score": 1.5,
+	},
+	map[string]interface{}{
+		"active":     false,
+		"created_at": nil,
+		"id":         int64(2),
+		"name":       "b",
+		"score":      2.0,
+	},
+}
+`,
+		},
+		{
+			name: "aliased time package",
+			data: "created_at\n2022-03-06T12:00:00Z\n",
+			opts: []dd.OptionFunc{dd.WithImports(map[string]string{"time": "stdtime"})},
+			want: `package fixtures
+
+import stdtime "time"
+
+var rows = []map[string]interface{}{
+	map[string]interface{}{
+		"created_at": func() stdtime.Time {
+			tmp, _ := stdtime.Parse(stdtime.RFC3339, "2022-03-06T12:00:00Z")
+			return tmp
+		}(),
+	},
+}
+`,
+		},
+		{
+			name:     "not finite numbers",
+			typeName: "Row",
+			data:     "a,b,c\nnan,inf,1\nNaN,Infinity,-1.5\n",
+			want: `package fixtures
+
+type Row struct {
+	A string  ` + "`" + `csv:"a"` + "`" + `
+	B string  ` + "`" + `csv:"b"` + "`" + `
+	C float64 ` + "`" + `csv:"c"` + "`" + `
+}
+
+var rows = []Row{
+	Row{
+		A: "nan",
+		B: "inf",
+		C: 1.0,
+	},
+	Row{
+		A: "NaN",
+		B: "Infinity",
+		C: -1.5,
+	},
+}
+`,
+		},
+		{
+			name:     "different number of fields",
+			typeName: "Row",
+			data:     "a,b,c\n1,2\n",
+			want: `package fixtures
+
+var rows = [][]string{
+	[]string{
+		"a", "b", "c",
+	},
+	[]string{
+		"1", "2",
+	},
+}
+`,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := dd.DumpCSVFile("fixtures", "rows", tc.typeName, []byte(tc.data), tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDumpCSVRecordsFile(t *testing.T) {
+	got, err := dd.DumpCSVRecordsFile("fixtures", "rows", []byte("1,a\n2,b,c\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package fixtures
+
+var rows = [][]string{
+	[]string{
+		"1", "a",
+	},
+	[]string{
+		"2", "b", "c",
+	},
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
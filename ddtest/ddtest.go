@@ -0,0 +1,284 @@
+// Package ddtest provides the helpers to test the output of dd.
+package ddtest
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Code-Hex/dd"
+)
+
+// verifiedVarName is the name of the variable declared in the synthetic file.
+const verifiedVarName = "ddtestVerifiedValue"
+
+var (
+	// the source importer caches the imported packages.
+	importerMu sync.Mutex
+	fset       = token.NewFileSet()
+	srcImport  = importer.ForCompiler(fset, "source", nil)
+)
+
+// VerifyCompiles reports an error if the output of dd for v can not be compiled
+// or the type of the output is different from reflect.TypeOf(v).
+//
+// The output is written to the synthetic file in the package of the caller,
+// so the types declared in the caller's package (including test files) can be used.
+// The synthetic file is parsed and type-checked with the caller's package
+// which are loaded from the source.
+func VerifyCompiles(t testing.TB, v interface{}, opts ...dd.OptionFunc) {
+	t.Helper()
+	pc, file, _, ok := runtime.Caller(1)
+	if !ok {
+		t.Fatal("failed to get the caller")
+		return
+	}
+	if err := verifyCompiles(callerPackagePath(pc), file, v, opts); err != nil {
+		t.Error(err)
+	}
+}
+
+func verifyCompiles(pkgPath, callerFile string, v interface{}, opts []dd.OptionFunc) error {
+	importerMu.Lock()
+	defer importerMu.Unlock()
+
+	files, err := parsePackageFiles(callerFile)
+	if err != nil {
+		return err
+	}
+	pkgName := files[0].Name.Name
+
+	// the types in the caller's package are written without the package name.
+	opts = append([]dd.OptionFunc{dd.WithPackagePath(pkgPath)}, opts...)
+	src, err := dd.DumpFile(pkgName, verifiedVarName, v, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to dump: %w", err)
+	}
+	filename := filepath.Join(filepath.Dir(callerFile), "ddtest_verify.go")
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return fmt.Errorf("failed to parse the dumped data: %w\n%s", err, src)
+	}
+
+	var errs []string
+	conf := &types.Config{
+		Importer:    srcImport,
+		FakeImportC: true,
+		Error: func(err error) {
+			// the errors in the caller's package are not reported.
+			if terr, ok := err.(types.Error); ok && terr.Fset.Position(terr.Pos).Filename == filename {
+				errs = append(errs, terr.Msg)
+			}
+		},
+	}
+	pkg, _ := conf.Check(pkgPath, fset, append(files, f), nil)
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to compile the dumped data: %s\n%s", strings.Join(errs, "\n"), src)
+	}
+
+	got := types.TypeString(pkg.Scope().Lookup(verifiedVarName).Type(), nil)
+	want := typeString(reflect.TypeOf(v))
+	if normalizeType(got) != normalizeType(want) {
+		return fmt.Errorf("the type of the dumped data is %s, but want %s\n%s", got, want, src)
+	}
+	return nil
+}
+
+// callerPackagePath returns the import path of the package of the function.
+func callerPackagePath(pc uintptr) string {
+	// e.g. github.com/Code-Hex/dd/ddtest_test.TestVerifyCompiles.func1
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		name = name[:slash+1+dot]
+	}
+	// the dots in the last element of the path are escaped.
+	return strings.ReplaceAll(name, "%2e", ".")
+}
+
+// parsePackageFiles parses the files in the same package as the file.
+func parsePackageFiles(file string) ([]*ast.File, error) {
+	caller, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the caller: %w", err)
+	}
+	dir := filepath.Dir(file)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the directory of the caller: %w", err)
+	}
+	var files []*ast.File
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if ok, err := build.Default.MatchFile(dir, name); err != nil || !ok {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %q: %w", name, err)
+		}
+		if f.Name.Name == caller.Name.Name {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// typeString returns typ in the same format as types.TypeString
+// which qualifies the types with the full package path.
+func typeString(typ reflect.Type) string {
+	var buf strings.Builder
+	writeType(&buf, typ)
+	return buf.String()
+}
+
+func writeType(buf *strings.Builder, typ reflect.Type) {
+	if typ.Name() != "" {
+		// the type arguments of the generic type are qualified
+		// with the full package path by reflect.
+		if pkgPath := typ.PkgPath(); pkgPath != "" {
+			buf.WriteString(pkgPath + "." + typ.Name())
+		} else {
+			// predeclared types. e.g. int, unsafe.Pointer
+			buf.WriteString(typ.String())
+		}
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Ptr:
+		buf.WriteString("*")
+		writeType(buf, typ.Elem())
+	case reflect.Slice:
+		buf.WriteString("[]")
+		writeType(buf, typ.Elem())
+	case reflect.Array:
+		fmt.Fprintf(buf, "[%d]", typ.Len())
+		writeType(buf, typ.Elem())
+	case reflect.Map:
+		buf.WriteString("map[")
+		writeType(buf, typ.Key())
+		buf.WriteString("]")
+		writeType(buf, typ.Elem())
+	case reflect.Chan:
+		switch typ.ChanDir() {
+		case reflect.RecvDir:
+			buf.WriteString("<-chan ")
+		case reflect.SendDir:
+			buf.WriteString("chan<- ")
+		default:
+			buf.WriteString("chan ")
+		}
+		elem := typ.Elem()
+		parens := typ.ChanDir() == reflect.BothDir && elem.Name() == "" &&
+			elem.Kind() == reflect.Chan && elem.ChanDir() == reflect.RecvDir
+		if parens {
+			buf.WriteString("(")
+		}
+		writeType(buf, elem)
+		if parens {
+			buf.WriteString(")")
+		}
+	case reflect.Func:
+		buf.WriteString("func")
+		writeSignature(buf, typ)
+	case reflect.Struct:
+		buf.WriteString("struct{")
+		for i := 0; i < typ.NumField(); i++ {
+			if i > 0 {
+				buf.WriteString("; ")
+			}
+			field := typ.Field(i)
+			if !field.Anonymous {
+				buf.WriteString(field.Name + " ")
+			}
+			writeType(buf, field.Type)
+			if field.Tag != "" {
+				fmt.Fprintf(buf, " %q", field.Tag)
+			}
+		}
+		buf.WriteString("}")
+	case reflect.Interface:
+		buf.WriteString("interface{")
+		for i := 0; i < typ.NumMethod(); i++ {
+			if i > 0 {
+				buf.WriteString("; ")
+			}
+			method := typ.Method(i)
+			if method.PkgPath != "" {
+				buf.WriteString(method.PkgPath + ".")
+			}
+			buf.WriteString(method.Name)
+			writeSignature(buf, method.Type)
+		}
+		buf.WriteString("}")
+	default:
+		buf.WriteString(typ.String())
+	}
+}
+
+func writeSignature(buf *strings.Builder, typ reflect.Type) {
+	buf.WriteString("(")
+	for i := 0; i < typ.NumIn(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		in := typ.In(i)
+		if typ.IsVariadic() && i == typ.NumIn()-1 {
+			buf.WriteString("...")
+			in = in.Elem()
+		}
+		writeType(buf, in)
+	}
+	buf.WriteString(")")
+	switch typ.NumOut() {
+	case 0:
+		return
+	case 1:
+		buf.WriteString(" ")
+		writeType(buf, typ.Out(0))
+		return
+	}
+	buf.WriteString(" (")
+	for i := 0; i < typ.NumOut(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		writeType(buf, typ.Out(i))
+	}
+	buf.WriteString(")")
+}
+
+var (
+	spacesRegexp  = regexp.MustCompile(`\s+`)
+	aliasesRegexp = regexp.MustCompile(`\b(byte|rune|any)\b`)
+)
+
+// normalizeType normalizes the type string to compare the types
+// which are written in different ways.
+func normalizeType(s string) string {
+	s = aliasesRegexp.ReplaceAllStringFunc(s, func(alias string) string {
+		switch alias {
+		case "byte":
+			return "uint8"
+		case "rune":
+			return "int32"
+		}
+		return "interface{}"
+	})
+	return spacesRegexp.ReplaceAllString(s, "")
+}
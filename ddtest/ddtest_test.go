@@ -0,0 +1,545 @@
+package ddtest_test
+
+import (
+	"fmt"
+	"math"
+	"net/textproto"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Code-Hex/dd"
+	"github.com/Code-Hex/dd/ddtest"
+	"github.com/google/go-cmp/cmp"
+)
+
+type item struct {
+	name  string
+	Price float64
+	Tags  []string
+	Attrs map[string]interface{}
+}
+
+type order struct {
+	Items    []*item
+	Header   textproto.MIMEHeader
+	Timeout  time.Duration
+	Callback func(int) (string, error)
+	Any      interface{}
+	Matrix   [2][2]complex64
+	Data     []byte
+	Anon     struct {
+		A int `json:"a"`
+	}
+}
+
+// recorder records the errors instead of failing the test.
+type recorder struct {
+	testing.TB
+	errs []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Error(args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprint(args...))
+}
+
+func (r *recorder) Fatal(args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprint(args...))
+}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func TestVerifyCompiles(t *testing.T) {
+	n := 10
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+	}{
+		{
+			name: "primitive",
+			v:    int64(math.MaxInt64),
+		},
+		{
+			name: "float",
+			v:    []float32{1, float32(math.Inf(-1))},
+		},
+		{
+			name: "local types",
+			v: &order{
+				Items: []*item{
+					{name: "book", Price: 9.5, Tags: []string{"a"}, Attrs: map[string]interface{}{"n": &n, "f": 1.0}},
+				},
+				Header:   textproto.MIMEHeader{"Key": {"value"}},
+				Timeout:  time.Second,
+				Callback: func(int) (string, error) { return "", nil },
+				Any:      []interface{}{uint8(1), "s", nil},
+				Matrix:   [2][2]complex64{{complex(1, math.Float32frombits(0x7fc00000))}},
+				Data:     []byte("data"),
+			},
+		},
+		{
+			name: "helper pointer",
+			v:    map[string]*int{"n": &n},
+			opts: []dd.OptionFunc{dd.WithPointerFormat(dd.HelperPointer)},
+		},
+		{
+			name: "custom dump func",
+			v:    []time.Time{time.Date(2022, 3, 6, 12, 0, 0, 0, time.UTC)},
+			opts: []dd.OptionFunc{
+				dd.WithDumpFunc(func(v time.Time, w dd.Writer) {
+					w.Write(fmt.Sprintf("time.Unix(%d, 0)", v.Unix()))
+				}),
+			},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			ddtest.VerifyCompiles(t, tc.v, tc.opts...)
+		})
+	}
+}
+
+func TestVerifyCompilesError(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "not compiled",
+			v:    make(chan int),
+			want: "failed to compile the dumped data",
+		},
+		{
+			name: "type mismatch",
+			v:    time.Second,
+			opts: []dd.OptionFunc{
+				dd.WithDumpFunc(func(v time.Duration, w dd.Writer) {
+					w.Write(fmt.Sprintf("%d", v))
+				}),
+			},
+			want: "the type of the dumped data is int, but want time.Duration",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			ddtest.VerifyCompiles(r, tc.v, tc.opts...)
+			if len(r.errs) != 1 {
+				t.Fatalf("want 1 error, but got %q", r.errs)
+			}
+			if !strings.Contains(r.errs[0], tc.want) {
+				t.Fatalf("want the error contains %q, but got %q", tc.want, r.errs[0])
+			}
+		})
+	}
+}
+
+func TestGolden(t *testing.T) {
+	n := 1
+	v := &order{
+		Items: []*item{{name: "book", Price: 9.5, Attrs: map[string]interface{}{"n": &n}}},
+		Any:   make(chan int),
+	}
+	ddtest.Golden(t, "order", v)
+}
+
+func TestGoldenMismatch(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "structural",
+			v:    []*item{{name: "book", Price: 10}},
+			want: `  []*ddtest_test.item{
+    &ddtest_test.item{
+      ... // 1 identical field
+-     Price: 9.5,
++     Price: 10.0,
+      ... // 2 identical fields
+    },
+  }`,
+		},
+		{
+			name: "lines",
+			v:    []interface{}{item{name: "b"}},
+			want: `  []interface {}{
+    ddtest_test.item{
+-     name: "a",
++     name: "b",
+      Price: 0.0,
+      Tags: ([]string)(nil),
+      Attrs: (map[string]interface {})(nil),
+    },
+  }`,
+		},
+		{
+			name: "missing",
+			v:    1,
+			want: "run the test with -ddtest.update flag to create it",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			ddtest.Golden(r, "value", tc.v)
+			if len(r.errs) != 1 {
+				t.Fatalf("want 1 error, but got %q", r.errs)
+			}
+			if !strings.Contains(r.errs[0], tc.want) {
+				t.Fatalf("want the error contains %q, but got %q", tc.want, r.errs[0])
+			}
+		})
+	}
+}
+
+func TestInline(t *testing.T) {
+	ddtest.Inline(t, []*item{{name: "book", Price: 9.5}}, []*item{
+		&item{
+			name:  "book",
+			Price: 9.5,
+			Tags:  ([]string)(nil),
+			Attrs: (map[string]interface{})(nil),
+		},
+	})
+	ddtest.Inline(t, time.Second, time.Duration(1000000000))
+}
+
+func TestInlineMismatch(t *testing.T) {
+	r := &recorder{TB: t}
+	ddtest.Inline(r, item{name: "b"}, item{name: "a"})
+	if len(r.errs) != 1 {
+		t.Fatalf("want 1 error, but got %q", r.errs)
+	}
+	want := `-   name: "a",
++   name: "b",`
+	if !strings.Contains(r.errs[0], want) {
+		t.Fatalf("want the error contains %q, but got %q", want, r.errs[0])
+	}
+}
+
+func TestInlineUpdate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the test which runs go test in short mode")
+	}
+	const src = `package inline
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd/ddtest"
+)
+
+type point struct{ X, Y int }
+
+func TestInline(t *testing.T) {
+	ddtest.Inline(t, []point{{X: 1, Y: 2}}, nil)
+	for _, v := range []uint8{1, 1} {
+		ddtest.Inline(t,
+			v,
+			0,
+		)
+	}
+	ddtest.Inline(t, timeouts(), nil)
+}
+`
+	// the test file does not import the package of the dumped data.
+	const helper = `package inline
+
+import "time"
+
+func timeouts() map[string]time.Duration {
+	return map[string]time.Duration{"a": time.Second}
+}
+`
+	const want = `package inline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Code-Hex/dd/ddtest"
+)
+
+type point struct{ X, Y int }
+
+func TestInline(t *testing.T) {
+	ddtest.Inline(t, []point{{X: 1, Y: 2}}, []point{
+		point{
+			X: 1,
+			Y: 2,
+		},
+	})
+	for _, v := range []uint8{1, 1} {
+		ddtest.Inline(t,
+			v,
+			uint8(1),
+		)
+	}
+	ddtest.Inline(t, timeouts(), map[string]time.Duration{
+		"a": 1000000000,
+	})
+}
+`
+	// the package is placed in the module to import ddtest package.
+	dir, err := os.MkdirTemp("testdata", "inline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "inline_test.go")
+	if err := os.WriteFile(filename, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "helper.go"), []byte(helper), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("go", "test", "-count=1", ".", "-ddtest.update")
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to run go test: %v\n%s", err, out)
+	}
+	got, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("(-want, +got)\n%s", diff)
+	}
+}
+
+func TestGoldenOwnUpdateFlag(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the test which runs go test in short mode")
+	}
+	// the test package defines -update flag for its own golden files.
+	const src = `package golden
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/Code-Hex/dd/ddtest"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGolden(t *testing.T) {
+	ddtest.Golden(t, "value", []int{1, 2})
+}
+`
+	dir, err := os.MkdirTemp("testdata", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, "golden_test.go"), []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"-update"}, nil} {
+		cmd := exec.Command("go", append([]string{"test", "-count=1", "."}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run go test %q: %v\n%s", args, err, out)
+		}
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "testdata", "TestGolden", "value.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[]int{\n  1,\n  2,\n}\n"; want != string(got) {
+		t.Errorf("want %q, but got %q", want, got)
+	}
+}
+
+func TestInlineUpdateImports(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the test which runs go test in short mode")
+	}
+	// the package of the value is imported with the alias.
+	const aliased = `package inline
+
+import (
+	"testing"
+
+	corev1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+	"github.com/Code-Hex/dd/ddtest"
+)
+
+func TestAliased(t *testing.T) {
+	ddtest.Inline(t, &corev1.Pod{Name: "a"}, nil)
+}
+`
+	// the other package named v1 is imported, and the pointer helper is used.
+	const collided = `package inline
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd"
+	"github.com/Code-Hex/dd/ddtest"
+	v1 "github.com/Code-Hex/dd/internal/testpkg/apps/v1"
+)
+
+func TestCollided(t *testing.T) {
+	ddtest.Inline(t, []interface{}{v1.Deployment{}, pod()}, nil)
+	ddtest.Inline(t, []*int{replicas()}, nil, dd.WithPointerFormat(dd.HelperPointer))
+}
+`
+	// the several packages including the standard one are missing.
+	const sorted = `package inline
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd/ddtest"
+)
+
+func TestSorted(t *testing.T) {
+	ddtest.Inline(t, values(), nil)
+}
+`
+	const helper = `package inline
+
+import (
+	"time"
+
+	appsv1 "github.com/Code-Hex/dd/internal/testpkg/apps/v1"
+	v1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+)
+
+func pod() *v1.Pod { return &v1.Pod{Name: "b"} }
+
+func values() []interface{} {
+	return []interface{}{v1.Meta{}, appsv1.Deployment{}, time.Duration(1)}
+}
+
+func replicas() *int {
+	n := 3
+	return &n
+}
+`
+	dir, err := os.MkdirTemp("testdata", "inline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"aliased_test.go":  aliased,
+		"collided_test.go": collided,
+		"sorted_test.go":   sorted,
+		"helper.go":        helper,
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the updated sources must be compiled and pass the test.
+	for _, args := range [][]string{{"-ddtest.update"}, nil} {
+		cmd := exec.Command("go", append([]string{"test", "-count=1", "."}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to run go test %q: %v\n%s", args, err, out)
+		}
+	}
+	for name, want := range map[string]string{
+		"aliased_test.go": `package inline
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd/ddtest"
+	corev1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+)
+
+func TestAliased(t *testing.T) {
+	ddtest.Inline(t, &corev1.Pod{Name: "a"}, &corev1.Pod{
+		Name: "a",
+		Meta: corev1.Meta{
+			Labels: (map[string]string)(nil),
+		},
+	})
+}
+`,
+		"collided_test.go": `package inline
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd"
+	"github.com/Code-Hex/dd/ddtest"
+	v1 "github.com/Code-Hex/dd/internal/testpkg/apps/v1"
+	corev1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+)
+
+func TestCollided(t *testing.T) {
+	ddtest.Inline(t, []interface{}{v1.Deployment{}, pod()}, []interface{}{
+		v1.Deployment{
+			Replicas: 0,
+		},
+		&corev1.Pod{
+			Name: "b",
+			Meta: corev1.Meta{
+				Labels: (map[string]string)(nil),
+			},
+		},
+	})
+	ddtest.Inline(t, []*int{replicas()}, []*int{
+		ptr(3),
+	}, dd.WithPointerFormat(dd.HelperPointer))
+}
+
+func ptr[T any](v T) *T { return &v }
+`,
+		"sorted_test.go": `package inline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Code-Hex/dd/ddtest"
+	appsv1 "github.com/Code-Hex/dd/internal/testpkg/apps/v1"
+	"github.com/Code-Hex/dd/internal/testpkg/core/v1"
+)
+
+func TestSorted(t *testing.T) {
+	ddtest.Inline(t, values(), []interface{}{
+		v1.Meta{
+			Labels: (map[string]string)(nil),
+		},
+		appsv1.Deployment{
+			Replicas: 0,
+		},
+		time.Duration(1),
+	})
+}
+`,
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Errorf("%s (-want, +got)\n%s", name, diff)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package ddtest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Code-Hex/dd"
+	"github.com/Code-Hex/dd/internal/diff"
+)
+
+// update is not named "update" not to conflict with the flag which
+// is commonly defined by the test packages for their golden files.
+var update = flag.Bool("ddtest.update", false, "update the golden files of ddtest.Golden and the literals of ddtest.Inline")
+
+// updating reports whether the expected values are rewritten.
+// The -update flag defined by the test package is also respected.
+func updating() bool {
+	if *update {
+		return true
+	}
+	if f := flag.Lookup("update"); f != nil {
+		if getter, ok := f.Value.(flag.Getter); ok {
+			b, _ := getter.Get().(bool)
+			return b
+		}
+	}
+	return false
+}
+
+// addressRegexp matches the pointer addresses which are different for each run.
+var addressRegexp = regexp.MustCompile(`uintptr\((0x[\da-f]+)\)`)
+
+// Golden compares the output of dd for v with the golden file
+// "testdata/<TestName>/<name>.golden". If -ddtest.update flag (or -update flag
+// defined by the test package) is set, the golden file is rewritten with
+// the output instead.
+//
+// The pointer addresses in the output are ignored in the comparison.
+// On failure, the difference is reported by dd.Diff if the golden file can be
+// undumped as the type of v. Otherwise, the difference of lines is reported.
+func Golden(t testing.TB, name string, v interface{}, opts ...dd.OptionFunc) {
+	t.Helper()
+	got := dd.Dump(v, opts...)
+	filename := filepath.Join("testdata", t.Name(), name+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
+			t.Fatalf("failed to create the directory: %v", err)
+			return
+		}
+		if err := os.WriteFile(filename, []byte(got+"\n"), 0o644); err != nil {
+			t.Fatalf("failed to update the golden file: %v", err)
+			return
+		}
+		return
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("failed to read the golden file (run the test with -ddtest.update flag to create it): %v", err)
+		return
+	}
+	want := strings.TrimSuffix(string(content), "\n")
+	if normalizeAddress(want) == normalizeAddress(got) {
+		return
+	}
+	t.Errorf("the output is different from %s (-want, +got):\n%s", filename, goldenDiff(want, got, v, opts))
+}
+
+func normalizeAddress(s string) string {
+	return addressRegexp.ReplaceAllString(s, "uintptr(0x0)")
+}
+
+// goldenDiff returns the structural difference if want can be undumped.
+func goldenDiff(want, got string, v interface{}, opts []dd.OptionFunc) string {
+	if typ := reflect.TypeOf(v); typ != nil {
+		wantValue := reflect.New(typ)
+		if err := dd.Undump(want, wantValue.Interface(), v); err == nil {
+			if d := dd.Diff(wantValue.Elem().Interface(), v, opts...); d != "" {
+				return d
+			}
+		}
+	}
+	return lineDiff(normalizeAddress(want), normalizeAddress(got))
+}
+
+func lineDiff(want, got string) string {
+	xs, ys := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var buf strings.Builder
+	for _, edit := range diff.Strings(xs, ys) {
+		switch edit.Op {
+		case diff.Equal:
+			buf.WriteString("  " + xs[edit.X] + "\n")
+		case diff.Delete:
+			buf.WriteString("- " + xs[edit.X] + "\n")
+		case diff.Insert:
+			buf.WriteString("+ " + ys[edit.Y] + "\n")
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
@@ -0,0 +1,364 @@
+package ddtest
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Code-Hex/dd"
+)
+
+// Inline compares got with want which is written as the literal in the test source.
+// The difference is reported by dd.Diff.
+//
+// If -ddtest.update flag (or -update flag defined by the test package) is set,
+// the want argument of the call in the test source is replaced with the output
+// of dd for got instead. The packages are qualified by the names imported in
+// the file, and the missing imports are added to the file. The helper function
+// of dd.HelperPointer is also added if the package does not declare it.
+// The file is formatted by gofmt.
+//
+//	ddtest.Inline(t, got, nil)
+//	// after "go test -ddtest.update"
+//	ddtest.Inline(t, got, []int{
+//		1,
+//		2,
+//	})
+func Inline(t testing.TB, got, want interface{}, opts ...dd.OptionFunc) {
+	t.Helper()
+	if !updating() {
+		if d := dd.Diff(want, got, opts...); d != "" {
+			t.Errorf("the value is different from want (run the test with -ddtest.update flag to update it) (-want, +got):\n%s", d)
+		}
+		return
+	}
+	pc, file, line, ok := runtime.Caller(1)
+	if !ok {
+		t.Fatal("failed to get the caller")
+		return
+	}
+	// the types in the caller's package are written without the package name.
+	opts = append([]dd.OptionFunc{dd.WithPackagePath(callerPackagePath(pc))}, opts...)
+	if err := updateInline(file, line, got, opts); err != nil {
+		t.Fatalf("failed to update the test source: %v", err)
+		return
+	}
+}
+
+var (
+	inlineMu sync.Mutex
+	// inlineFiles is the test sources being updated.
+	// The sources are updated from the original source for each call
+	// because the positions of the callers are based on it.
+	inlineFiles = make(map[string]*inlineFile)
+)
+
+type inlineFile struct {
+	src  []byte
+	fset *token.FileSet
+	file *ast.File
+	// replacements is the replaced want arguments keyed by the offset.
+	replacements map[int]edit
+	// imports is the import paths which are added with the names.
+	imports map[string]string
+	// helper is the declaration of the pointer helper which is added.
+	helper string
+}
+
+// edit replaces the source between start and end offsets with text.
+type edit struct {
+	start, end int
+	text       string
+}
+
+func updateInline(filename string, line int, got interface{}, opts []dd.OptionFunc) error {
+	inlineMu.Lock()
+	defer inlineMu.Unlock()
+
+	f, ok := inlineFiles[filename]
+	if !ok {
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		f = &inlineFile{
+			src:          src,
+			fset:         fset,
+			file:         file,
+			replacements: make(map[int]edit),
+			imports:      make(map[string]string),
+		}
+		inlineFiles[filename] = f
+	}
+
+	wantArg := f.findWantArg(line)
+	if wantArg == nil {
+		return fmt.Errorf("the call of Inline is not found at %s:%d", filename, line)
+	}
+	// the packages imported in the file are qualified by their names.
+	opts = append([]dd.OptionFunc{dd.WithImports(f.importNames())}, opts...)
+	text, imports, helper, err := dumpExpr(got, opts)
+	if err != nil {
+		return err
+	}
+	if helper != "" && f.helper == "" {
+		declared, err := declaresPtr(filename)
+		if err != nil {
+			return err
+		}
+		if !declared {
+			f.helper = helper
+		}
+	}
+	start := f.fset.Position(wantArg.Pos()).Offset
+	end := f.fset.Position(wantArg.End()).Offset
+	if r, ok := f.replacements[start]; ok && r.text != text {
+		return fmt.Errorf("the call at %s:%d is called with the different values", filename, line)
+	}
+	f.replacements[start] = edit{start: start, end: end, text: text}
+	for pkgPath, name := range imports {
+		f.imports[pkgPath] = name
+	}
+
+	src, err := format.Source(f.updatedSource())
+	if err != nil {
+		return fmt.Errorf("failed to format the updated source: %w", err)
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, src, info.Mode())
+}
+
+// importNames returns the names of the packages imported in the file
+// keyed by the path, including the imports which are added.
+// The name is empty if the import is not renamed.
+func (f *inlineFile) importNames() map[string]string {
+	names := make(map[string]string)
+	for _, spec := range f.file.Imports {
+		pkgPath, _ := strconv.Unquote(spec.Path.Value)
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		// the blank and dot imports can not be used to qualify the identifiers.
+		if name == "_" || name == "." {
+			continue
+		}
+		names[pkgPath] = name
+	}
+	for pkgPath, name := range f.imports {
+		names[pkgPath] = name
+	}
+	return names
+}
+
+// declaresPtr reports whether the package of the file declares "ptr"
+// which is the name of the pointer helper.
+func declaresPtr(filename string) (bool, error) {
+	files, err := parsePackageFiles(filename)
+	if err != nil {
+		return false, err
+	}
+	for _, file := range files {
+		if file.Scope.Lookup("ptr") != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// findWantArg returns the want argument of the call of Inline at the line.
+func (f *inlineFile) findWantArg(line int) ast.Expr {
+	var ret ast.Expr
+	ast.Inspect(f.file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || ret != nil {
+			return ret == nil
+		}
+		// the line of the caller may be any line of the call expression.
+		if line < f.fset.Position(call.Pos()).Line || f.fset.Position(call.End()).Line < line {
+			return false
+		}
+		var name string
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			name = fun.Sel.Name
+		case *ast.Ident:
+			name = fun.Name
+		}
+		if name == "Inline" && len(call.Args) >= 3 {
+			ret = call.Args[2]
+			return false
+		}
+		return true
+	})
+	return ret
+}
+
+// updatedSource returns the original source applied the replacements
+// and the missing imports.
+func (f *inlineFile) updatedSource() []byte {
+	var edits []edit
+	for _, e := range f.replacements {
+		edits = append(edits, e)
+	}
+
+	imported := make(map[string]bool)
+	for _, spec := range f.file.Imports {
+		pkgPath, _ := strconv.Unquote(spec.Path.Value)
+		imported[pkgPath] = true
+	}
+	var missing []string
+	for pkgPath := range f.imports {
+		if !imported[pkgPath] {
+			missing = append(missing, pkgPath)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		edits = append(edits, f.importEdits(missing)...)
+	}
+	if f.helper != "" {
+		edits = append(edits, edit{start: len(f.src), end: len(f.src), text: "\n" + f.helper + "\n"})
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var buf bytes.Buffer
+	offset := 0
+	for _, e := range edits {
+		buf.Write(f.src[offset:e.start])
+		buf.WriteString(e.text)
+		offset = e.end
+	}
+	buf.Write(f.src[offset:])
+	return buf.Bytes()
+}
+
+// importEdits returns the edits to insert the import specs of the sorted paths.
+// The standard packages are added after the last standard package imported in
+// the file, and the others are added to the last group, so that gofmt sorts
+// them in each group.
+func (f *inlineFile) importEdits(paths []string) []edit {
+	var std, others bytes.Buffer
+	for _, pkgPath := range paths {
+		spec := "\n" + f.imports[pkgPath] + " " + strconv.Quote(pkgPath)
+		if isStdPackage(pkgPath) {
+			std.WriteString(spec)
+		} else {
+			others.WriteString(spec)
+		}
+	}
+	for _, decl := range f.file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() {
+			continue
+		}
+		// the standard packages are placed at the top if none is imported.
+		stdOffset := f.fset.Position(gen.Lparen).Offset + 1
+		hasStd, lastIsStd := false, false
+		for _, spec := range gen.Specs {
+			pkgPath, _ := strconv.Unquote(spec.(*ast.ImportSpec).Path.Value)
+			lastIsStd = isStdPackage(pkgPath)
+			if lastIsStd {
+				hasStd = true
+				stdOffset = f.fset.Position(spec.End()).Offset
+			}
+		}
+		var edits []edit
+		if std.Len() > 0 {
+			text := std.String()
+			if !hasStd && len(gen.Specs) > 0 {
+				text += "\n"
+			}
+			edits = append(edits, edit{start: stdOffset, end: stdOffset, text: text})
+		}
+		if others.Len() > 0 {
+			// the specs are inserted before the closing parenthesis,
+			// and separated from the group of the standard packages.
+			offset := f.fset.Position(gen.Rparen).Offset
+			text := others.String()[1:] + "\n"
+			if lastIsStd {
+				text = "\n" + text
+			}
+			edits = append(edits, edit{start: offset, end: offset, text: text})
+		}
+		return edits
+	}
+	specs := std.String()
+	if std.Len() > 0 && others.Len() > 0 {
+		specs += "\n"
+	}
+	specs += others.String()
+	offset := f.fset.Position(f.file.Name.End()).Offset
+	return []edit{{start: offset, end: offset, text: "\n\nimport (" + specs + "\n)"}}
+}
+
+// isStdPackage reports whether pkgPath is the path of the standard package,
+// whose first element does not contain the dot as goimports does.
+func isStdPackage(pkgPath string) bool {
+	elem := pkgPath
+	if i := strings.Index(pkgPath, "/"); i >= 0 {
+		elem = pkgPath[:i]
+	}
+	return !strings.Contains(elem, ".")
+}
+
+// dumpExpr returns the expression of got whose type is kept even if it is
+// passed as interface{}, the imports used in the expression and the declaration
+// of the pointer helper if it is used.
+func dumpExpr(got interface{}, opts []dd.OptionFunc) (expr string, imports map[string]string, helper string, err error) {
+	src, err := dd.DumpFile("inline", "want", got, opts...)
+	if err != nil {
+		return "", nil, "", err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, 0)
+	if err != nil {
+		return "", nil, "", err
+	}
+	imports = make(map[string]string)
+	for _, spec := range file.Imports {
+		pkgPath, _ := strconv.Unquote(spec.Path.Value)
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[pkgPath] = name
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				continue
+			}
+			value := decl.Specs[0].(*ast.ValueSpec).Values[0]
+			start, end := fset.Position(value.Pos()).Offset, fset.Position(value.End()).Offset
+			expr = string(src[start:end])
+		case *ast.FuncDecl:
+			// the pointer helper of dd.HelperPointer
+			start, end := fset.Position(decl.Pos()).Offset, fset.Position(decl.End()).Offset
+			helper = string(src[start:end])
+		}
+	}
+	if expr == "" {
+		return "", nil, "", fmt.Errorf("the dumped data is not found")
+	}
+	return expr, imports, helper, nil
+}
@@ -30,6 +30,24 @@ func TestWithDumpFunc(t *testing.T) {
 			want:       "func() *big.Int {\n  tmp := new(big.Int)\n  tmp.SetString(\"10\")\n  return tmp\n}()",
 			dumpOption: df.WithBigInt(),
 		},
+		{
+			name:       "pointer to time",
+			v:          func() *time.Time { v := time.Date(2022, 3, 6, 12, 0, 0, 0, time.UTC); return &v }(),
+			want:       "func() *time.Time { v := func() time.Time {\n  tmp, _ := time.Parse(\"Mon Jan _2 15:04:05 MST 2006\", \"Sun Mar  6 12:00:00 UTC 2022\")\n  return tmp\n}(); return &v }()",
+			dumpOption: df.WithTime(time.UnixDate),
+		},
+		{
+			name:       "big int value",
+			v:          []big.Int{*big.NewInt(10)},
+			want:       "[]big.Int{\n  *func() *big.Int {\n    tmp := new(big.Int)\n    tmp.SetString(\"10\")\n    return tmp\n  }(),\n}",
+			dumpOption: df.WithBigInt(),
+		},
+		{
+			name:       "nil big int",
+			v:          (*big.Int)(nil),
+			want:       "(*big.Int)(nil)",
+			dumpOption: df.WithBigInt(),
+		},
 		{
 			name:       "big float",
 			v:          big.NewFloat(12345.6789),
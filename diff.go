@@ -0,0 +1,305 @@
+package dd
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Hex/dd/internal/diff"
+	"github.com/Code-Hex/dd/internal/sort"
+)
+
+// Diff returns the difference between want and got in Go syntax.
+// It returns the empty string if both are dumped as the same string.
+//
+// The values are compared structurally with the same rules as Dump and the options.
+// The removed lines are prefixed with "-", the added lines are prefixed with "+"
+// and the identical fields or elements are omitted like this:
+//
+//	  main.Config{
+//	-   Name: "a",
+//	+   Name: "b",
+//	    ... // 2 identical fields
+//	  }
+func Diff(want, got interface{}, opts ...OptionFunc) string {
+	d := newDataDumper(io.Discard, nil, opts...)
+	// the shared references are not written as variables in the diff.
+	d.graph = nil
+	df := &differ{
+		d:       d,
+		visited: make(map[[2]uintptr]bool),
+	}
+	x, y := reflect.ValueOf(want), reflect.ValueOf(got)
+	if df.render(x) == df.render(y) {
+		return ""
+	}
+	df.diff("", x, y, "")
+	return strings.TrimSuffix(df.buf.String(), "\n")
+}
+
+// differ writes the difference of two values.
+type differ struct {
+	d     *dumper
+	buf   strings.Builder
+	depth int
+	// visited records the pairs of pointers being compared to avoid the infinite recursion.
+	visited map[[2]uintptr]bool
+}
+
+// render returns v dumped as string.
+func (df *differ) render(v reflect.Value) string {
+	if !v.IsValid() {
+		return "nil"
+	}
+	return df.d.sprint(v)
+}
+
+// writeLines writes s with the mark at the current depth.
+func (df *differ) writeLines(mark byte, s string) {
+	indent := strings.Repeat(df.d.indentUnit, df.depth)
+	for _, line := range strings.Split(s, "\n") {
+		df.buf.WriteByte(mark)
+		df.buf.WriteString(" " + indent + line + "\n")
+	}
+}
+
+// writeIdentical writes the line which means the identical values are omitted.
+func (df *differ) writeIdentical(n int, unit string) {
+	if n == 0 {
+		return
+	}
+	if n > 1 {
+		if strings.HasSuffix(unit, "y") {
+			unit = strings.TrimSuffix(unit, "y") + "ie"
+		}
+		unit += "s"
+	}
+	df.writeLines(' ', "... // "+strconv.Itoa(n)+" identical "+unit)
+}
+
+// diff writes the difference of x and y written between prefix and suffix.
+// e.g. the field name and the comma.
+func (df *differ) diff(prefix string, x, y reflect.Value, suffix string) {
+	xs, ys := df.render(x), df.render(y)
+	if xs == ys {
+		df.writeLines(' ', prefix+xs+suffix)
+		return
+	}
+	if !df.comparable(x, y) {
+		df.writeLines('-', prefix+xs+suffix)
+		df.writeLines('+', prefix+ys+suffix)
+		return
+	}
+	switch x.Kind() {
+	case reflect.Interface:
+		df.diff(prefix, x.Elem(), y.Elem(), suffix)
+		return
+	case reflect.Ptr:
+		key := [2]uintptr{x.Pointer(), y.Pointer()}
+		df.visited[key] = true
+		df.diff(prefix+"&", x.Elem(), y.Elem(), suffix)
+		delete(df.visited, key)
+		return
+	}
+
+	df.writeLines(' ', prefix+df.d.typeString(x.Type())+"{")
+	df.depth++
+	switch x.Kind() {
+	case reflect.Struct:
+		df.diffStruct(x, y)
+	case reflect.Slice, reflect.Array:
+		df.diffList(x, y)
+	case reflect.Map:
+		df.diffMap(x, y)
+	}
+	df.depth--
+	df.writeLines(' ', "}"+suffix)
+}
+
+// comparable reports whether the difference of x and y can be written
+// structurally. Otherwise, these are written as the removed and added values.
+func (df *differ) comparable(x, y reflect.Value) bool {
+	if !x.IsValid() || !y.IsValid() || x.Type() != y.Type() {
+		return false
+	}
+	// the value is written by the custom function.
+	if _, ok := df.d.customDumpFunc(x); ok {
+		return false
+	}
+	switch x.Kind() {
+	case reflect.Interface:
+		if x.IsNil() || y.IsNil() || x.Elem().Type() != y.Elem().Type() {
+			return false
+		}
+		// the primitive values are written with the conversion.
+		switch x.Elem().Kind() {
+		case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map, reflect.Ptr:
+			return df.comparable(x.Elem(), y.Elem())
+		}
+		return false
+	case reflect.Ptr:
+		if x.IsNil() || y.IsNil() || df.visited[[2]uintptr{x.Pointer(), y.Pointer()}] {
+			return false
+		}
+		// the pointer is written as &T{...} only for these values.
+		if !isAddressableLiteral(x.Elem()) || !isAddressableLiteral(y.Elem()) {
+			return false
+		}
+		if _, ok := df.d.customDumpFunc(x.Elem()); ok {
+			return false
+		}
+		return true
+	case reflect.Struct, reflect.Array:
+		return true
+	case reflect.Slice, reflect.Map:
+		return !x.IsNil() && !y.IsNil() && x.Len() > 0 && y.Len() > 0
+	}
+	return false
+}
+
+func (df *differ) diffStruct(x, y reflect.Value) {
+	identical := 0
+	for i := 0; i < x.NumField(); i++ {
+		field := x.Type().Field(i)
+		if df.d.exportedOnly && !isExported(field) {
+			continue
+		}
+		fx, fy := x.Field(i), y.Field(i)
+		if df.render(fx) == df.render(fy) {
+			identical++
+			continue
+		}
+		df.writeIdentical(identical, "field")
+		identical = 0
+		df.diff(field.Name+": ", fx, fy, ",")
+	}
+	df.writeIdentical(identical, "field")
+}
+
+// diffList writes the difference of the elements which are aligned by
+// the longest common subsequence of them.
+func (df *differ) diffList(x, y reflect.Value) {
+	xs := make([]string, x.Len())
+	for i := range xs {
+		xs[i] = df.render(x.Index(i))
+	}
+	ys := make([]string, y.Len())
+	for i := range ys {
+		ys[i] = df.render(y.Index(i))
+	}
+	edits := diff.Strings(xs, ys)
+	identical := 0
+	for i := 0; i < len(edits); {
+		if edits[i].Op == diff.Equal {
+			identical++
+			i++
+			continue
+		}
+		df.writeIdentical(identical, "element")
+		identical = 0
+
+		// the removed elements followed by the added elements are
+		// compared each other as the modified elements if these are similar.
+		var removed, added []int
+		for ; i < len(edits) && edits[i].Op == diff.Delete; i++ {
+			removed = append(removed, edits[i].X)
+		}
+		for ; i < len(edits) && edits[i].Op == diff.Insert; i++ {
+			added = append(added, edits[i].Y)
+		}
+		for _, ix := range removed {
+			k := 0
+			for k < len(added) && !df.similar(x.Index(ix), y.Index(added[k])) {
+				k++
+			}
+			if k == len(added) {
+				df.writeLines('-', xs[ix]+",")
+				continue
+			}
+			for _, iy := range added[:k] {
+				df.writeLines('+', ys[iy]+",")
+			}
+			df.diff("", x.Index(ix), y.Index(added[k]), ",")
+			added = added[k+1:]
+		}
+		for _, iy := range added {
+			df.writeLines('+', ys[iy]+",")
+		}
+	}
+	df.writeIdentical(identical, "element")
+}
+
+// similar reports whether x and y are similar enough to be written as
+// the modified element. Otherwise, these are written as the removed and
+// added elements not to misalign the elements after the shift of the list.
+//
+// The structs are similar if at least half of the fields are identical,
+// where the fields which are zero in both are not counted.
+// The other values are similar if at least half of the lines in the braces
+// are identical.
+func (df *differ) similar(x, y reflect.Value) bool {
+	if !df.comparable(x, y) {
+		return false
+	}
+	for x.Kind() == reflect.Interface || x.Kind() == reflect.Ptr {
+		x, y = x.Elem(), y.Elem()
+	}
+	var identical, total int
+	if x.Kind() == reflect.Struct {
+		for i := 0; i < x.NumField(); i++ {
+			if df.d.exportedOnly && !isExported(x.Type().Field(i)) {
+				continue
+			}
+			fx, fy := x.Field(i), y.Field(i)
+			if fx.IsZero() && fy.IsZero() {
+				continue
+			}
+			total++
+			if df.render(fx) == df.render(fy) {
+				identical++
+			}
+		}
+		return identical > 0 && identical*2 >= total
+	}
+	xl, yl := strings.Split(df.render(x), "\n"), strings.Split(df.render(y), "\n")
+	if len(xl) < 3 || len(yl) < 3 {
+		return false
+	}
+	xl, yl = xl[1:len(xl)-1], yl[1:len(yl)-1]
+	for _, e := range diff.Strings(xl, yl) {
+		if e.Op == diff.Equal {
+			identical++
+		}
+	}
+	total = len(xl)
+	if len(yl) > total {
+		total = len(yl)
+	}
+	return identical > 0 && identical*2 >= total
+}
+
+// diffMap writes the difference of the entries in the order of the sorted keys.
+func (df *differ) diffMap(x, y reflect.Value) {
+	keys := sort.Keys(append(x.MapKeys(), y.MapKeys()...))
+	identical := 0
+	for _, key := range keys {
+		vx, vy := x.MapIndex(key), y.MapIndex(key)
+		if vx.IsValid() && vy.IsValid() && df.render(vx) == df.render(vy) {
+			identical++
+			continue
+		}
+		df.writeIdentical(identical, "entry")
+		identical = 0
+		prefix := df.render(key) + ": "
+		switch {
+		case !vy.IsValid():
+			df.writeLines('-', prefix+df.render(vx)+",")
+		case !vx.IsValid():
+			df.writeLines('+', prefix+df.render(vy)+",")
+		default:
+			df.diff(prefix, vx, vy, ",")
+		}
+	}
+	df.writeIdentical(identical, "entry")
+}
@@ -0,0 +1,189 @@
+package dd_test
+
+import (
+	"testing"
+
+	"github.com/Code-Hex/dd"
+	"github.com/google/go-cmp/cmp"
+)
+
+type diffItem struct {
+	Name  string
+	Price int
+	Tags  []string
+	note  string
+}
+
+type diffOrder struct {
+	ID    int
+	Items []*diffItem
+	Attrs map[string]interface{}
+	Any   interface{}
+}
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		name string
+		want interface{}
+		got  interface{}
+		opts []dd.OptionFunc
+		diff string
+	}{
+		{
+			name: "equal",
+			want: diffOrder{ID: 1},
+			got:  diffOrder{ID: 1},
+			diff: "",
+		},
+		{
+			name: "primitive",
+			want: 1,
+			got:  2,
+			diff: "- 1\n+ 2",
+		},
+		{
+			name: "different types",
+			want: []interface{}{int64(1)},
+			got:  []interface{}{"1"},
+			diff: "  []interface {}{\n-   int64(1),\n+   \"1\",\n  }",
+		},
+		{
+			name: "struct fields",
+			want: &diffOrder{ID: 1, Attrs: map[string]interface{}{"a": 1, "b": 2, "c": 3}},
+			got:  &diffOrder{ID: 2, Attrs: map[string]interface{}{"a": 1, "b": "2", "d": 4}},
+			diff: `  &dd_test.diffOrder{
+-   ID: 1,
++   ID: 2,
+    ... // 1 identical field
+    Attrs: map[string]interface {}{
+      ... // 1 identical entry
+-     "b": 2,
++     "b": "2",
+-     "c": 3,
++     "d": 4,
+    },
+    ... // 1 identical field
+  }`,
+		},
+		{
+			name: "shifted list",
+			want: []*diffItem{{Name: "a"}, {Name: "b", Tags: []string{"x", "y"}}, {Name: "c"}},
+			got:  []*diffItem{{Name: "new"}, {Name: "a"}, {Name: "b", Tags: []string{"x", "z"}}, {Name: "c"}},
+			diff: `  []*dd_test.diffItem{
++   &dd_test.diffItem{
++     Name: "new",
++     Price: 0,
++     Tags: ([]string)(nil),
++     note: "",
++   },
+    ... // 1 identical element
+    &dd_test.diffItem{
+      ... // 2 identical fields
+      Tags: []string{
+        ... // 1 identical element
+-       "y",
++       "z",
+      },
+      ... // 1 identical field
+    },
+    ... // 1 identical element
+  }`,
+		},
+		{
+			name: "shifted list of structs",
+			want: []diffItem{{Name: "a", Price: 1}, {Name: "b", Price: 2}, {Name: "c", Price: 3}},
+			got:  []diffItem{{Name: "b", Price: 20}, {Name: "c", Price: 3}, {Name: "d", Price: 4}},
+			diff: `  []dd_test.diffItem{
+-   dd_test.diffItem{
+-     Name: "a",
+-     Price: 1,
+-     Tags: ([]string)(nil),
+-     note: "",
+-   },
+    dd_test.diffItem{
+      ... // 1 identical field
+-     Price: 2,
++     Price: 20,
+      ... // 2 identical fields
+    },
+    ... // 1 identical element
++   dd_test.diffItem{
++     Name: "d",
++     Price: 4,
++     Tags: ([]string)(nil),
++     note: "",
++   },
+  }`,
+		},
+		{
+			name: "replaced elements",
+			want: []diffItem{{Name: "a", Price: 1}},
+			got:  []diffItem{{Name: "b", Price: 2}},
+			diff: `  []dd_test.diffItem{
+-   dd_test.diffItem{
+-     Name: "a",
+-     Price: 1,
+-     Tags: ([]string)(nil),
+-     note: "",
+-   },
++   dd_test.diffItem{
++     Name: "b",
++     Price: 2,
++     Tags: ([]string)(nil),
++     note: "",
++   },
+  }`,
+		},
+		{
+			name: "exported only",
+			want: diffItem{Name: "a", note: "x"},
+			got:  diffItem{Name: "b", note: "y"},
+			opts: []dd.OptionFunc{dd.WithExportedOnly()},
+			diff: "  dd_test.diffItem{\n-   Name: \"a\",\n+   Name: \"b\",\n    ... // 2 identical fields\n  }",
+		},
+		{
+			name: "interface",
+			want: diffOrder{Any: &diffItem{Price: 1}},
+			got:  diffOrder{Any: &diffItem{Price: 2}},
+			diff: `  dd_test.diffOrder{
+    ... // 3 identical fields
+    Any: &dd_test.diffItem{
+      ... // 1 identical field
+-     Price: 1,
++     Price: 2,
+      ... // 2 identical fields
+    },
+  }`,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Diff(tc.want, tc.got, tc.opts...)
+			if d := cmp.Diff(tc.diff, got); d != "" {
+				t.Fatalf("(-want, +got)\n%s\n%s", d, got)
+			}
+		})
+	}
+}
+
+func TestDiffCircular(t *testing.T) {
+	a := &listNode{Val: 1}
+	a.Next = a
+	b := &listNode{Val: 2}
+	b.Next = b
+	if got := dd.Diff(a, b); got == "" {
+		t.Fatal("want the difference")
+	}
+}
+
+func TestDiffWithDumpFunc(t *testing.T) {
+	opt := dd.WithDumpFunc(func(v diffItem, w dd.Writer) {
+		w.Write(`item("` + v.Name + `")`)
+	})
+	got := dd.Diff([]diffItem{{Name: "a"}}, []diffItem{{Name: "b"}}, opt)
+	want := "  []dd_test.diffItem{\n-   item(\"a\"),\n+   item(\"b\"),\n  }"
+	if want != got {
+		t.Fatalf("want %q, but got %q", want, got)
+	}
+}
@@ -4,10 +4,10 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
-	"sync"
 	"text/tabwriter"
 
 	"github.com/Code-Hex/dd/internal/sort"
@@ -19,165 +19,251 @@ type options struct {
 	exportedOnly     bool
 	indentSize       int
 	uintFormat       UintFormat
+	ptrFormat        PointerFormat
+	graph            bool
+	goStringer       bool
+	packagePath      string
+	fileImports      map[string]string
 	convertibleTypes map[reflect.Type]dumpFunc
+	// interfaceDumpFuncs is the functions registered for the interface types.
+	interfaceDumpFuncs map[reflect.Type]dumpFunc
+	// matchDumpFuncs is the functions registered with the predicates in order of registration.
+	matchDumpFuncs   []matchDumpFunc
+	kindDumpFuncs    map[reflect.Kind]dumpFunc
 	listGroupingSize map[reflect.Type]int
 }
 
+// matchDumpFunc is the function used for the types which match reports true.
+type matchDumpFunc struct {
+	match func(reflect.Type) bool
+	f     dumpFunc
+}
+
 func newDefaultOptions() *options {
 	return &options{
-		exportedOnly:     false,
-		indentSize:       2,
-		uintFormat:       DecimalUint,
-		convertibleTypes: map[reflect.Type]dumpFunc{},
-		listGroupingSize: map[reflect.Type]int{},
+		exportedOnly:       false,
+		indentSize:         2,
+		uintFormat:         DecimalUint,
+		ptrFormat:          InlinePointer,
+		fileImports:        map[string]string{},
+		convertibleTypes:   map[reflect.Type]dumpFunc{},
+		interfaceDumpFuncs: map[reflect.Type]dumpFunc{},
+		kindDumpFuncs:      map[reflect.Kind]dumpFunc{},
+		listGroupingSize:   map[reflect.Type]int{},
+	}
+}
+
+// addDumpFunc registers f for typ.
+func (o *options) addDumpFunc(typ reflect.Type, f dumpFunc) {
+	if typ.Kind() == reflect.Interface {
+		o.interfaceDumpFuncs[typ] = f
+		return
 	}
+	o.convertibleTypes[typ] = f
 }
 
 type dumper struct {
-	buf              *strings.Builder
-	tw               *tabwriter.Writer
-	value            reflect.Value
-	depth            int
+	w     io.Writer
+	err   error
+	value reflect.Value
+	depth int
+	// needType reports whether the value is held in an interface.
+	// In this case, the type of the value can not be inferred from the context.
+	needType         bool
+	path             []pathElem
+	indentUnit       string
 	visitPointers    map[uintptr]bool
 	cachedZeroValues map[reflect.Type]string
-	clonePool        *sync.Pool
+	// cachedDumpFuncs is the results of typeDumpFunc for each type.
+	cachedDumpFuncs map[reflect.Type]dumpFunc
+	graph           *graph
+	// imports records the packages referred in the output if it is not nil.
+	imports       *imports
+	usedPtrHelper bool
+	// typeNames is the names of the unnamed types declared in the output.
+	typeNames map[reflect.Type]string
+	// pkgNames is the names of the packages seen in the types keyed by the path.
+	pkgNames map[string]string
+	// strict reports whether the unrepresentable values are recorded.
+	strict          bool
+	unrepresentable []UnrepresentableValue
 	// options
-	exportedOnly     bool
-	uintFormat       UintFormat
-	convertibleTypes map[reflect.Type]dumpFunc
-	listGroupingSize map[reflect.Type]int
-}
-
-var _ interface {
-	fmt.Stringer
-} = (*dumper)(nil)
-
-func newDataDumper(obj interface{}, optFuncs ...OptionFunc) *dumper {
+	optFuncs           []OptionFunc
+	exportedOnly       bool
+	indentSize         int
+	uintFormat         UintFormat
+	ptrFormat          PointerFormat
+	goStringer         bool
+	packagePath        string
+	fileImports        map[string]string
+	convertibleTypes   map[reflect.Type]dumpFunc
+	interfaceDumpFuncs map[reflect.Type]dumpFunc
+	matchDumpFuncs     []matchDumpFunc
+	kindDumpFuncs      map[reflect.Kind]dumpFunc
+	listGroupingSize   map[reflect.Type]int
+}
+
+func newDataDumper(w io.Writer, obj interface{}, optFuncs ...OptionFunc) *dumper {
 	opts := newDefaultOptions()
 	// apply options
 	for _, apply := range optFuncs {
 		apply(opts)
 	}
-	clonePool := &sync.Pool{
-		New: func() interface{} {
-			buf := new(strings.Builder)
-			return &dumper{
-				buf: buf,
-				tw:  tabwriter.NewWriter(buf, opts.indentSize, 0, 1, ' ', 0),
-			}
-		},
-	}
-	ret := clonePool.Get().(*dumper)
-	ret.value = valueOf(obj, true)
-	ret.clonePool = clonePool
-	ret.visitPointers = make(map[uintptr]bool)
-	ret.cachedZeroValues = zeroPrimitives
-	ret.clonePool = clonePool
-	ret.exportedOnly = opts.exportedOnly
-	ret.uintFormat = opts.uintFormat
-	ret.convertibleTypes = opts.convertibleTypes
-	ret.listGroupingSize = opts.listGroupingSize
-	return ret
-}
-
-func (d *dumper) clone(obj interface{}) *dumper {
-	child := d.clonePool.Get().(*dumper)
-	child.value = valueOf(obj, false)
-	child.depth = d.depth
-	child.visitPointers = d.visitPointers
-	child.cachedZeroValues = d.cachedZeroValues
-	child.clonePool = d.clonePool
-	child.exportedOnly = d.exportedOnly
-	child.uintFormat = d.uintFormat
-	child.convertibleTypes = d.convertibleTypes
-	child.listGroupingSize = d.listGroupingSize
-	return child
-}
-
-func dumpclone(d *dumper, obj interface{}) string {
-	cloned := d.clone(obj).build()
-	ret := cloned.String()
-	cloned.release()
-	return ret
-}
-
-func (d *dumper) release() {
-	d.buf.Reset()
-	d.clonePool.Put(d)
+	// The indent is the same width as an empty cell of tabwriter
+	// which has minwidth is the indent size and padding is 1.
+	indentWidth := opts.indentSize
+	if indentWidth < 1 {
+		indentWidth = 1
+	}
+	var g *graph
+	if opts.graph {
+		g = newGraph()
+	}
+	return &dumper{
+		w:                  w,
+		value:              valueOf(obj, true),
+		indentUnit:         strings.Repeat(" ", indentWidth),
+		visitPointers:      make(map[uintptr]bool),
+		cachedZeroValues:   make(map[reflect.Type]string),
+		cachedDumpFuncs:    make(map[reflect.Type]dumpFunc),
+		graph:              g,
+		optFuncs:           optFuncs,
+		exportedOnly:       opts.exportedOnly,
+		indentSize:         opts.indentSize,
+		uintFormat:         opts.uintFormat,
+		ptrFormat:          opts.ptrFormat,
+		goStringer:         opts.goStringer,
+		packagePath:        opts.packagePath,
+		fileImports:        opts.fileImports,
+		convertibleTypes:   opts.convertibleTypes,
+		interfaceDumpFuncs: opts.interfaceDumpFuncs,
+		matchDumpFuncs:     opts.matchDumpFuncs,
+		kindDumpFuncs:      opts.kindDumpFuncs,
+		listGroupingSize:   opts.listGroupingSize,
+	}
+}
+
+// dump writes obj at the current depth. The value which is being dumped
+// is restored after that.
+func (d *dumper) dump(obj interface{}) {
+	parent := d.value
+	d.value = valueOf(obj, false)
+	d.build()
+	d.value = parent
+}
+
+// dumpTyped is similar to dump, but obj is dumped so that the type is kept
+// even if obj is written where the type can not be inferred from the context.
+func (d *dumper) dumpTyped(obj interface{}) {
+	d.needType = true
+	d.dump(obj)
+}
+
+// sprint returns obj dumped at the current depth as string.
+// It is used for small parts of the output which must be cached or measured.
+func (d *dumper) sprint(obj interface{}) string {
+	var buf strings.Builder
+	w := d.w
+	d.w = &buf
+	d.dump(obj)
+	d.w = w
+	return buf.String()
 }
 
 func (d *dumper) indent() string {
-	return strings.Repeat("\t", d.depth)
+	return strings.Repeat(d.indentUnit, d.depth)
 }
 
-func (d *dumper) String() string {
-	d.tw.Flush()
-	return d.buf.String()
+// dumpRoot dumps the root value. In graph-aware mode, the shared values
+// are declared before the root value.
+func (d *dumper) dumpRoot() {
+	if d.graph != nil && d.writeGraph() {
+		return
+	}
+	d.build()
 }
 
-func (d *dumper) build() *dumper {
+func (d *dumper) build() {
+	needType := d.needType
+	d.needType = false
+	if d.err != nil {
+		return
+	}
 	kind := d.value.Kind()
 	if kind == reflect.Invalid {
+		// the untyped nil can not be assigned to the variable
+		// whose type is inferred. e.g. var data = nil
+		if needType {
+			d.writeRaw("interface{}(nil)")
+			return
+		}
 		d.writeRaw("nil")
-		return d
+		return
 	}
 
-	convertFunc, ok := d.convertibleTypes[d.value.Type()]
+	convertFunc, ok := d.customDumpFunc(d.value)
 	if ok {
+		d.addImportCandidate(d.value.Type())
 		convertFunc(d.value, &dumpWriter{d})
-		return d
+		return
 	}
 	switch kind {
 	case reflect.Bool:
-		d.writeBool(d.value.Bool())
-		return d
+		d.writeConversion(needType, boolType, func() {
+			d.writeBool(d.value.Bool())
+		})
+		return
 	case reflect.String:
-		d.writeString(d.value.String())
-		return d
+		d.writeConversion(needType, stringType, func() {
+			d.writeString(d.value.String())
+		})
+		return
 	case reflect.Array:
 		d.writeArray()
-		return d
+		return
 	case reflect.Slice:
 		d.writeSlice()
-		return d
+		return
 	case reflect.Map:
 		d.writeMap()
-		return d
+		return
 	case reflect.Chan:
 		d.writeChan()
-		return d
+		return
 	case reflect.Func:
 		d.writeFunc()
-		return d
+		return
 	case reflect.Struct:
 		d.writeStruct()
-		return d
+		return
 	case reflect.Interface:
 		d.writeInterface()
-		return d
+		return
 	case reflect.UnsafePointer:
-		d.printf("%s(uintptr(%v))", d.value.Type().String(), d.value.Pointer())
-		return d
+		if d.value.Pointer() != 0 {
+			d.report("unsafe pointer")
+		}
+		d.printf("%s(uintptr(%v))", d.typeString(d.value.Type()), d.value.Pointer())
+		return
 	case reflect.Ptr:
 		d.writePtr()
-		return d
+		return
 	}
 	if isNumber(kind) {
-		d.writeNumber()
-		return d
+		d.writeNumber(needType)
+		return
 	}
 	// NOTE(codehex): perhaps this block is unnecessary
 	if d.value.CanInterface() {
 		d.printf("%v", d.value.Interface())
-		return d
+		return
 	}
 	d.writeRaw(d.value.String())
-	return d
 }
 
 func (d *dumper) writeFunc() {
 	if d.value.IsNil() {
-		d.printf("(%s)(nil)", d.value.Type().String())
+		d.printf("(%s)(nil)", d.typeString(d.value.Type()))
 		return
 	}
 
@@ -187,19 +273,19 @@ func (d *dumper) writeFunc() {
 	}
 	defer cleanup()
 
+	d.report("function body")
 	typ := d.value.Type()
-	funcTyp := typ.String()
-	d.writeRaw(funcTyp)
+	d.writeRaw(d.typeString(typ))
 	// check anonymous function or not.
 	// e.g. context.CancelFunc => context.CancelFunc(func() {})
-	isNotAnonymous := !strings.HasPrefix(funcTyp, "func(")
+	isNotAnonymous := typ.Name() != ""
 	if isNotAnonymous {
 		d.writeRaw("(func(")
 		for i := 0; i < typ.NumIn(); i++ {
 			if i > 0 {
 				d.writeRaw(", ")
 			}
-			d.writeRaw(typ.In(i).String())
+			d.writeRaw(d.typeString(typ.In(i)))
 		}
 		d.writeRaw(")")
 	}
@@ -232,14 +318,20 @@ func (d *dumper) zeroValue(rt reflect.Type) string {
 	if cached, ok := d.cachedZeroValues[rt]; ok {
 		return cached
 	}
-	zero := dumpclone(d, reflect.Zero(rt))
+	if cached, ok := zeroPrimitives[rt]; ok {
+		return cached
+	}
+	zero := d.sprint(reflect.Zero(rt))
 	d.cachedZeroValues[rt] = zero
 	return zero
 }
 
 func (d *dumper) writePtr() {
 	if d.value.IsNil() {
-		d.printf("(%s)(nil)", d.value.Type())
+		d.printf("(%s)(nil)", d.typeString(d.value.Type()))
+		return
+	}
+	if d.writeGraphRef() {
 		return
 	}
 	cleanup, ok := d.writeVisitedPointer()
@@ -250,21 +342,187 @@ func (d *dumper) writePtr() {
 
 	// dereference
 	deref := d.value.Elem()
-	kind := deref.Kind()
-	if kind == reflect.Ptr {
-		d.writePointer()
+	// the function for the pointee may not write a composite literal.
+	if _, ok := d.customDumpFunc(deref); ok || !isAddressableLiteral(deref) {
+		d.writePtrOf(deref)
 		return
 	}
-	if isPrimitive(kind) {
-		d.writePointer()
-		return
+	d.writeRaw("&")
+	d.dumpElem(pathElem{kind: derefPath, typ: d.value.Type()}, deref)
+}
+
+var (
+	dumperType     = reflect.TypeOf((*Dumper)(nil)).Elem()
+	goStringerType = reflect.TypeOf((*fmt.GoStringer)(nil)).Elem()
+)
+
+// customDumpFunc returns the function to dump v instead of the default format.
+//
+// The function registered by WithDumpFunc for the type of v takes precedence.
+// If v is not a pointer and the function is registered for the pointer type,
+// it is used with dereferencing like *f(&v). Then the function found by
+// matchedDumpFunc is used. The nil pointers are always dumped as nil,
+// and the pointers to the values which have the function are dumped as
+// the pointers to the values dumped by the function (see writePtr).
+func (d *dumper) customDumpFunc(v reflect.Value) (dumpFunc, bool) {
+	typ := v.Type()
+	if typ.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	if f, ok := d.convertibleTypes[typ]; ok {
+		return f, true
+	}
+	if typ.Kind() == reflect.Ptr {
+		if _, ok := d.convertibleTypes[typ.Elem()]; ok {
+			return nil, false
+		}
+	} else if f, ok := d.convertibleTypes[reflect.PtrTo(typ)]; ok && v.CanInterface() {
+		return derefDumpFunc(f), true
+	}
+	return d.matchedDumpFunc(v)
+}
+
+// derefDumpFunc returns the function which dumps the value by f registered
+// for the pointer type.
+func derefDumpFunc(f dumpFunc) dumpFunc {
+	return func(rv reflect.Value, w Writer) {
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		w.Write("*")
+		f(ptr, w)
+	}
+}
+
+// matchedDumpFunc returns the function to dump v found by typeDumpFunc.
+// The pointer is not dumped by the function if the type of the pointee
+// has the function, because it is dumped as the pointer to the value
+// dumped by the function.
+func (d *dumper) matchedDumpFunc(v reflect.Value) (dumpFunc, bool) {
+	typ := v.Type()
+	if typ.Kind() == reflect.Interface || !v.CanInterface() {
+		return nil, false
+	}
+	if typ.Kind() == reflect.Ptr && (v.IsNil() || d.typeDumpFunc(typ.Elem()) != nil) {
+		return nil, false
+	}
+	f := d.typeDumpFunc(typ)
+	return f, f != nil
+}
+
+// typeDumpFunc returns the function to dump the values of typ other than
+// the functions registered for the exact types. It returns nil if there
+// is no function.
+//
+// The functions are looked up in this order:
+//
+//  1. registered by WithDumpFunc for the interfaces which typ implements
+//  2. Dumper
+//  3. registered by WithMatchDumpFunc for the predicates which match typ
+//  4. registered by WithKindDumpFunc for the kind of typ
+//  5. fmt.GoStringer if WithGoStringer is specified
+//
+// If typ implements multiple registered interfaces, the most specific one
+// (which has the most methods) is used. If typ matches multiple predicates,
+// the last registered one is used.
+func (d *dumper) typeDumpFunc(typ reflect.Type) dumpFunc {
+	if f, ok := d.cachedDumpFuncs[typ]; ok {
+		return f
+	}
+	var f dumpFunc
+	var best reflect.Type
+	for iface, g := range d.interfaceDumpFuncs {
+		if !typ.Implements(iface) || !moreSpecific(iface, best) {
+			continue
+		}
+		f, best = g, iface
 	}
-	convertFunc, ok := d.convertibleTypes[deref.Type()]
-	if ok {
-		convertFunc(d.value, &dumpWriter{d})
-		return
+	// the type's own format is preferred to the generic hooks.
+	if f == nil && typ.Implements(dumperType) {
+		f = dumpByDumper
+	}
+	if f == nil {
+		for i := len(d.matchDumpFuncs) - 1; i >= 0; i-- {
+			if m := d.matchDumpFuncs[i]; m.match(typ) {
+				f = m.f
+				break
+			}
+		}
+	}
+	if f == nil {
+		f = d.kindDumpFuncs[typ.Kind()]
+	}
+	if f == nil && d.goStringer && typ.Implements(goStringerType) {
+		f = dumpByGoStringer
+	}
+	d.cachedDumpFuncs[typ] = f
+	return f
+}
+
+// moreSpecific reports whether the interface a is more specific than b.
+// The interface which has more methods is more specific, and the string of
+// the types is compared if these have the same number of methods.
+func moreSpecific(a, b reflect.Type) bool {
+	if b == nil {
+		return true
+	}
+	if a.NumMethod() != b.NumMethod() {
+		return a.NumMethod() > b.NumMethod()
+	}
+	return a.String() < b.String()
+}
+
+func dumpByDumper(rv reflect.Value, w Writer) {
+	rv.Interface().(Dumper).DumpDD(w)
+}
+
+func dumpByGoStringer(rv reflect.Value, w Writer) {
+	w.Write(rv.Interface().(fmt.GoStringer).GoString())
+}
+
+// isAddressableLiteral reports whether the address of v can be taken
+// like &T{...} when v is dumped.
+func isAddressableLiteral(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array:
+		return true
+	case reflect.Slice, reflect.Map:
+		// nil is dumped as conversion like ([]int)(nil)
+		return !v.IsNil()
+	}
+	return false
+}
+
+// writePtrOf writes the pointer to deref whose address can not be taken directly.
+// e.g. *int, *string, **T
+func (d *dumper) writePtrOf(deref reflect.Value) {
+	d.pushPath(pathElem{kind: derefPath, typ: d.value.Type()})
+	defer d.popPath()
+	typ := d.typeString(deref.Type())
+	// the value held in interface may be other type,
+	// so it is needed to declare the type explicitly.
+	isInterface := deref.Kind() == reflect.Interface
+	switch d.ptrFormat {
+	case HelperPointer:
+		d.usedPtrHelper = true
+		if isInterface {
+			d.printf("ptr[%s](", typ)
+			d.dump(deref)
+		} else {
+			d.writeRaw("ptr(")
+			d.dumpTyped(deref)
+		}
+		d.writeRaw(")")
+	default:
+		d.printf("func() *%s { ", typ)
+		if isInterface {
+			d.printf("var v %s = ", typ)
+			d.dump(deref)
+		} else {
+			d.writeRaw("v := ")
+			d.dumpTyped(deref)
+		}
+		d.writeRaw("; return &v }()")
 	}
-	d.printf("&%s", dumpclone(d, deref))
 }
 
 func (d *dumper) writeStruct() {
@@ -272,6 +530,8 @@ func (d *dumper) writeStruct() {
 
 	// records the i'th field
 	fieldIdxs := make([]int, 0, numField)
+	// the unexported fields can be written only in the same package.
+	hasForeignField := false
 
 	for i := 0; i < numField; i++ {
 		field := d.value.Type().Field(i)
@@ -279,13 +539,19 @@ func (d *dumper) writeStruct() {
 			continue
 		}
 		fieldIdxs = append(fieldIdxs, i)
+		if !isExported(field) && field.PkgPath != d.packagePath {
+			hasForeignField = true
+		}
 	}
 	if len(fieldIdxs) == 0 {
-		d.printf("%s{}", d.value.Type().String())
+		d.printf("%s{}", d.typeString(d.value.Type()))
 		return
 	}
 
-	d.writeRaw(d.value.Type().String())
+	d.writeRaw(d.typeString(d.value.Type()))
+	if hasForeignField {
+		d.report("unexported fields of %s", d.value.Type())
+	}
 	d.writeBlock(func() {
 		for _, idx := range fieldIdxs {
 			field := d.value.Type().Field(idx)
@@ -293,7 +559,9 @@ func (d *dumper) writeStruct() {
 			if !isExported(field) && fieldVal.CanAddr() {
 				fieldVal = getUnexportedField(fieldVal)
 			}
-			d.indentedPrintf("%s: %s,\n", field.Name, dumpclone(d, fieldVal))
+			d.writeIndentedRaw(field.Name + ": ")
+			d.dumpElem(pathElem{kind: fieldPath, name: field.Name}, fieldVal)
+			d.writeRaw(",\n")
 		}
 	})
 }
@@ -301,9 +569,10 @@ func (d *dumper) writeStruct() {
 // writeChan writes channel info. format will be like `(chan int)(nil)`
 func (d *dumper) writeChan() {
 	if d.value.IsNil() {
-		d.printf("(%s)(nil)", d.value.Type().String())
+		d.printf("(%s)(nil)", d.typeString(d.value.Type()))
 		return
 	}
+	d.report("channel")
 	d.writePointer()
 }
 
@@ -311,11 +580,14 @@ func (d *dumper) writeMap() {
 	// We must check if it is nil before checking length.
 	// because the length of nil map is 0.
 	if d.value.IsNil() {
-		d.printf("(%s)(nil)", d.value.Type().String())
+		d.printf("(%s)(nil)", d.typeString(d.value.Type()))
+		return
+	}
+	if d.writeGraphRef() {
 		return
 	}
 	if d.value.Len() == 0 {
-		d.printf("%s{}", d.value.Type().String())
+		d.printf("%s{}", d.typeString(d.value.Type()))
 		return
 	}
 
@@ -325,17 +597,29 @@ func (d *dumper) writeMap() {
 	}
 	defer cleanup()
 
-	d.writeRaw(d.value.Type().String())
+	d.writeRaw(d.typeString(d.value.Type()))
 
 	d.writeBlock(func() {
+		// Values of the map are aligned with tabwriter like gofmt.
+		// A line which has no tabs (e.g. nested multi-line values) terminates
+		// the column block, then buffered lines are flushed to the parent writer.
+		// So the memory used by buffering is bounded to a run of such lines.
+		parent := d.w
+		tw := tabwriter.NewWriter(parent, d.indentSize, 0, 1, ' ', 0)
+		d.w = tw
 		keys := sort.Keys(d.value.MapKeys())
 		for _, key := range keys {
 			val := d.value.MapIndex(key)
-			d.indentedPrintf("%s:\t%s,\n",
-				dumpclone(d, key),
-				dumpclone(d, val),
-			)
+			d.writeRaw(strings.Repeat("\t", d.depth))
+			d.dump(key)
+			d.writeRaw(":\t")
+			d.dumpElem(pathElem{kind: mapKeyPath, key: key}, val)
+			d.writeRaw(",\n")
+		}
+		if err := tw.Flush(); err != nil && d.err == nil {
+			d.err = err
 		}
+		d.w = parent
 	})
 }
 
@@ -343,7 +627,7 @@ func (d *dumper) writeSlice() {
 	// We must check if it is nil before checking length.
 	// because the length of nil slice is 0.
 	if d.value.IsNil() {
-		d.printf("(%s)(nil)", d.value.Type().String())
+		d.printf("(%s)(nil)", d.typeString(d.value.Type()))
 		return
 	}
 
@@ -358,10 +642,10 @@ func (d *dumper) writeSlice() {
 
 func (d *dumper) writeArray() {
 	if d.value.Len() == 0 {
-		d.printf("%s{}", d.value.Type().String())
+		d.printf("%s{}", d.typeString(d.value.Type()))
 		return
 	}
-	d.writeRaw(d.value.Type().String())
+	d.writeRaw(d.typeString(d.value.Type()))
 	d.writeList()
 }
 
@@ -378,10 +662,12 @@ func (d *dumper) writeList() {
 			mod := (i + 1) % size
 			breakLine = mod == 0
 			if size == 1 || mod == 1 {
-				d.indentedPrintf("%s,", dumpclone(d, elem))
+				d.writeIndent()
 			} else {
-				d.printf(" %s,", dumpclone(d, elem))
+				d.writeRaw(" ")
 			}
+			d.dumpElem(pathElem{kind: indexPath, index: i, typ: d.value.Type()}, elem)
+			d.writeRaw(",")
 			if breakLine {
 				d.writeRaw("\n")
 			}
@@ -395,33 +681,123 @@ func (d *dumper) writeList() {
 func (d *dumper) writeInterface() {
 	elem := d.value.Elem()
 	if elem.IsValid() {
-		d.writeRaw(dumpclone(d, elem))
+		d.pushPath(pathElem{kind: interfacePath, typ: elem.Type()})
+		d.dumpTyped(elem)
+		d.popPath()
 		return
 	}
 	d.writeRaw("nil")
 }
 
-func (d *dumper) writeNumber() {
+func (d *dumper) writeNumber(needType bool) {
 	switch d.value.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		d.printf("%d", d.value.Int())
+		d.writeConversion(needType, intType, func() {
+			d.printf("%d", d.value.Int())
+		})
 		return
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		d.writeUnsignedInt()
+		// the default type of integer literals is always int.
+		d.writeConversion(needType, intType, d.writeUnsignedInt)
 		return
 	case reflect.Float32, reflect.Float64:
-		d.printf("%f", d.value.Float())
+		d.writeFloat(d.value.Float(), d.value.Type(), needType)
 		return
-	case reflect.Complex64:
-		d.printf("%v", complex64(d.value.Complex()))
-		return
-	case reflect.Complex128:
-		d.printf("%v", d.value.Complex())
+	case reflect.Complex64, reflect.Complex128:
+		d.writeComplex(d.value.Complex(), d.value.Type(), needType)
 		return
 	}
 	panic(fmt.Errorf("unreachable type: %s", d.value.Type()))
 }
 
+// writeFloat writes f as the shortest literal which is parsed as the same value.
+// NaN, ±Inf and negative zero can not be written as literals, so these are
+// written with functions of math package. e.g. math.NaN()
+// These are converted to typ if typ is not float64 because the functions return float64.
+func (d *dumper) writeFloat(f float64, typ reflect.Type, needType bool) {
+	special, ok := d.formatSpecialFloat(f)
+	if !ok {
+		d.writeConversion(needType, float64Type, func() {
+			d.writeRaw(formatFloat(f, typ.Bits()))
+		})
+		return
+	}
+	if typ == float64Type {
+		d.writeRaw(special)
+		return
+	}
+	d.printf("%s(%s)", d.typeString(typ), special)
+}
+
+// writeComplex writes c as complex(real, imag) using the same format as floats.
+//
+// complex(real, imag) which parts are literals is complex128 constant. If a part
+// of complex64 is NaN, ±Inf or negative zero, the part is converted to float32
+// so the result will be complex64. The result is converted to typ if it is not
+// inferred from the context or it can not be assigned to typ.
+func (d *dumper) writeComplex(c complex128, typ reflect.Type, needType bool) {
+	bitSize := typ.Bits() / 2
+	realPart, realSpecial := d.formatComplexPart(real(c), bitSize)
+	imagPart, imagSpecial := d.formatComplexPart(imag(c), bitSize)
+	hasSpecial := realSpecial || imagSpecial
+
+	named := typ != complex64Type && typ != complex128Type
+	typed := typ == complex128Type || (typ == complex64Type && hasSpecial)
+	if (named && hasSpecial) || (needType && !typed) {
+		d.printf("%s(complex(%s, %s))", d.typeString(typ), realPart, imagPart)
+		return
+	}
+	d.printf("complex(%s, %s)", realPart, imagPart)
+}
+
+var (
+	complex64Type  = reflect.TypeOf(complex64(0))
+	complex128Type = reflect.TypeOf(complex128(0))
+)
+
+// formatComplexPart formats f as a part of complex number. It reports whether
+// f is formatted with math package.
+func (d *dumper) formatComplexPart(f float64, bitSize int) (string, bool) {
+	special, ok := d.formatSpecialFloat(f)
+	if !ok {
+		return formatFloat(f, bitSize), false
+	}
+	if bitSize == 32 {
+		return "float32(" + special + ")", true
+	}
+	return special, true
+}
+
+// formatFloat formats finite f as the shortest float literal which represents
+// exactly the same value in bitSize. The result always looks like a float literal
+// (e.g. "1.0" instead of "1") so that untyped constant is not treated as an integer.
+func formatFloat(f float64, bitSize int) string {
+	s := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// formatSpecialFloat formats f as expressions using math package
+// if f can not be written as literal.
+func (d *dumper) formatSpecialFloat(f float64) (string, bool) {
+	var fn string
+	switch {
+	case math.IsNaN(f):
+		fn = "NaN()"
+	case math.IsInf(f, 1):
+		fn = "Inf(1)"
+	case math.IsInf(f, -1):
+		fn = "Inf(-1)"
+	case f == 0 && math.Signbit(f):
+		fn = "Copysign(0, -1)"
+	default:
+		return "", false
+	}
+	return d.qualify("math", "math") + "." + fn, true
+}
+
 func (d *dumper) writeUnsignedInt() {
 	switch d.value.Kind() {
 	case reflect.Uint8:
@@ -466,8 +842,9 @@ func (d *dumper) writeUnsignedInt() {
 
 func (d *dumper) writePointer() {
 	d.printf(
-		"(%s)(unsafe.Pointer(uintptr(0x%x)))",
-		d.value.Type().String(),
+		"(%s)(%s.Pointer(uintptr(0x%x)))",
+		d.typeString(d.value.Type()),
+		d.qualify("unsafe", "unsafe"),
 		d.value.Pointer(),
 	)
 }
@@ -475,6 +852,7 @@ func (d *dumper) writePointer() {
 func (d *dumper) writeVisitedPointer() (func(), bool) {
 	pointer := d.value.Pointer()
 	if d.visitPointers[pointer] {
+		d.report("circular reference")
 		d.writePointer()
 		return nil, true
 	}
@@ -492,6 +870,28 @@ func (d *dumper) writeBlock(f func()) {
 	d.writeIndentedRaw("}")
 }
 
+// Default types of untyped constants.
+var (
+	boolType    = reflect.TypeOf(false)
+	stringType  = reflect.TypeOf("")
+	intType     = reflect.TypeOf(int(0))
+	float64Type = reflect.TypeOf(float64(0))
+)
+
+// writeConversion writes the literal by f. If the type of the value can not be
+// inferred from the context (e.g. held in an interface) and it is not the default
+// type of the literal, the literal is converted to the type. e.g. int8(1)
+func (d *dumper) writeConversion(needType bool, defaultType reflect.Type, f func()) {
+	typ := d.value.Type()
+	if !needType || typ == defaultType {
+		f()
+		return
+	}
+	d.writeRaw(d.typeString(typ) + "(")
+	f()
+	d.writeRaw(")")
+}
+
 func (d *dumper) writeBool(b bool) {
 	d.writeRaw(strconv.FormatBool(b))
 }
@@ -517,13 +917,33 @@ func (d *dumper) indentedPrintf(format string, a ...interface{}) {
 	d.printf(format, a...)
 }
 
-// writeRaw appends the contents of s to p's buffer.
+// writeRaw writes the contents of s to d's writer.
+// Once writing has failed, any subsequent writes are skipped and
+// the first error is kept in d.err.
 func (d *dumper) writeRaw(s string) {
-	io.WriteString(d.tw, s)
+	if d.err != nil {
+		return
+	}
+	_, d.err = io.WriteString(d.w, s)
 }
 
 func (d *dumper) printf(format string, a ...interface{}) {
-	fmt.Fprintf(d.tw, format, a...)
+	if d.err != nil {
+		return
+	}
+	_, d.err = fmt.Fprintf(d.w, format, a...)
+}
+
+// countWriter counts the number of bytes written to the underlying writer.
+type countWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
 }
 
 type dumpWriter struct{ *dumper }
@@ -541,3 +961,28 @@ func (d *dumpWriter) WriteBlock(s string) {
 	d.dumper.depth--
 	d.dumper.writeIndentedRaw("}")
 }
+
+func (d *dumpWriter) WriteValue(v interface{}) { d.dumper.dump(v) }
+
+func (d *dumpWriter) WriteField(name string, v interface{}) {
+	d.dumper.writeRaw("\n")
+	d.dumper.writeIndentedRaw(name + ": ")
+	d.dumper.dumpElem(pathElem{kind: fieldPath, name: name}, v)
+	d.dumper.writeRaw(",")
+}
+
+func (d *dumpWriter) Indent() { d.dumper.depth++ }
+
+func (d *dumpWriter) Dedent() {
+	d.dumper.depth--
+	d.dumper.writeRaw("\n")
+	d.dumper.writeIndent()
+}
+
+func (d *dumpWriter) Path() string { return d.dumper.pathString() }
+
+func (d *dumpWriter) Depth() int { return d.dumper.depth }
+
+func (d *dumpWriter) Options() []OptionFunc {
+	return append([]OptionFunc(nil), d.dumper.optFuncs...)
+}
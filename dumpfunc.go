@@ -12,6 +12,15 @@ var typeWriter = reflect.TypeOf((*Writer)(nil)).Elem()
 
 // WithDumpFunc is an option to add function for customize specified type dump string.
 // want function f like "func(string, Writer)"
+//
+// If the first parameter is an interface type, f is used for the values whose
+// types implement it. If the type implements multiple interfaces which are
+// registered, the most specific one is used.
+//
+// The function for a type is also used for the pointer to the type like
+// func() *T { v := f(...); return &v }(), and the function for a pointer type
+// is also used for the pointee like *f(...). The function for the exact type
+// takes precedence. The nil pointers are always dumped as nil.
 func WithDumpFunc(f interface{}) OptionFunc {
 	frv := reflect.ValueOf(f)
 	typ := frv.Type()
@@ -27,9 +36,11 @@ func WithDumpFunc(f interface{}) OptionFunc {
 	if !p1.Implements(typeWriter) {
 		panic("the second parameter must be implemented interface Writer")
 	}
+	fn := dumpFunc(func(rv reflect.Value, w Writer) {
+		// the value is converted to the interface type if p0 is.
+		frv.Call([]reflect.Value{rv.Convert(p0), reflect.ValueOf(w)})
+	})
 	return func(o *options) {
-		o.convertibleTypes[p0] = dumpFunc(func(rv reflect.Value, w Writer) {
-			frv.Call([]reflect.Value{rv, reflect.ValueOf(w)})
-		})
+		o.addDumpFunc(p0, fn)
 	}
 }
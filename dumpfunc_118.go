@@ -11,12 +11,22 @@ import (
 type DumpFunc[T any] func(T, Writer)
 
 // WithDumpFunc is an option to add function for customize specified type dump string.
+//
+// If T is an interface type, f is used for the values whose types implement T.
+// If the type implements multiple interfaces which are registered,
+// the most specific one is used.
+//
+// The function for a type is also used for the pointer to the type like
+// func() *T { v := f(...); return &v }(), and the function for a pointer type
+// is also used for the pointee like *f(...). The function for the exact type
+// takes precedence. The nil pointers are always dumped as nil.
 func WithDumpFunc[T any](f DumpFunc[T]) OptionFunc {
-	var v T
-	typ := reflect.TypeOf(v)
+	// reflect.TypeOf returns nil for the zero value of the interface type.
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	fn := dumpFunc(func(rv reflect.Value, w Writer) {
+		f(rv.Interface().(T), w)
+	})
 	return func(o *options) {
-		o.convertibleTypes[typ] = dumpFunc(func(rv reflect.Value, w Writer) {
-			f(rv.Interface().(T), w)
-		})
+		o.addDumpFunc(typ, fn)
 	}
 }
@@ -0,0 +1,430 @@
+package dd_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/parser"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/Code-Hex/dd"
+)
+
+type celsius float64
+
+func (c celsius) DumpDD(w dd.Writer) {
+	w.Write(fmt.Sprintf("dd_test.celsius(%g)", float64(c)))
+}
+
+type version struct {
+	major, minor int
+}
+
+func (v version) GoString() string {
+	return fmt.Sprintf("dd_test.newVersion(%d, %d)", v.major, v.minor)
+}
+
+type registry struct {
+	name string
+}
+
+// DumpDD is declared with the pointer receiver.
+func (r *registry) DumpDD(w dd.Writer) {
+	w.Write(fmt.Sprintf("dd_test.newRegistry(%q)", r.name))
+}
+
+// both is dumped by DumpDD rather than GoString.
+type both struct{}
+
+func (both) DumpDD(w dd.Writer) { w.Write("dd_test.both{}") }
+
+func (both) GoString() string { return "GoString" }
+
+func TestDumper(t *testing.T) {
+	temp := celsius(36.5)
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "value",
+			v:    []celsius{1.5},
+			want: "[]dd_test.celsius{\n  dd_test.celsius(1.5),\n}",
+		},
+		{
+			name: "pointer to value receiver",
+			v:    &temp,
+			want: "func() *dd_test.celsius { v := dd_test.celsius(36.5); return &v }()",
+		},
+		{
+			name: "pointer receiver",
+			v:    map[string]*registry{"a": {name: "a"}, "nil": nil},
+			want: "map[string]*dd_test.registry{\n  \"a\":   dd_test.newRegistry(\"a\"),\n  \"nil\": (*dd_test.registry)(nil),\n}",
+		},
+		{
+			name: "in interface",
+			v:    []interface{}{celsius(1)},
+			want: "[]interface {}{\n  dd_test.celsius(1),\n}",
+		},
+		{
+			name: "dump func takes precedence",
+			v:    celsius(1),
+			opts: []dd.OptionFunc{
+				dd.WithDumpFunc(func(v celsius, w dd.Writer) {
+					w.Write("dd_test.celsius(0)")
+				}),
+			},
+			want: "dd_test.celsius(0)",
+		},
+		{
+			name: "go stringer is disabled",
+			v:    version{major: 1},
+			want: "dd_test.version{\n  major: 1,\n  minor: 0,\n}",
+		},
+		{
+			name: "go stringer",
+			v:    []*version{{major: 1, minor: 2}},
+			opts: []dd.OptionFunc{dd.WithGoStringer()},
+			want: "[]*dd_test.version{\n  func() *dd_test.version { v := dd_test.newVersion(1, 2); return &v }(),\n}",
+		},
+		{
+			name: "dumper takes precedence over go stringer",
+			v:    both{},
+			opts: []dd.OptionFunc{dd.WithGoStringer()},
+			want: "dd_test.both{}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, tc.opts...)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type notFound struct {
+	key string
+}
+
+func (e *notFound) Error() string { return e.key + " is not found" }
+
+func (e *notFound) Detail() string { return "key: " + e.key }
+
+type detailedError interface {
+	error
+	Detail() string
+}
+
+type status int
+
+func (s status) String() string { return "status" + strconv.Itoa(int(s)) }
+
+func TestWithDumpFuncInterface(t *testing.T) {
+	dumpError := dd.WithDumpFunc(func(err error, w dd.Writer) {
+		w.Write(fmt.Sprintf("errors.New(%q)", err.Error()))
+	})
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "error",
+			v:    []error{errors.New("a"), &notFound{key: "b"}, nil},
+			opts: []dd.OptionFunc{dumpError},
+			want: "[]error{\n  errors.New(\"a\"),\n  errors.New(\"b is not found\"),\n  nil,\n}",
+		},
+		{
+			name: "most specific",
+			v:    []error{errors.New("a"), &notFound{key: "b"}},
+			opts: []dd.OptionFunc{
+				dd.WithDumpFunc(func(err detailedError, w dd.Writer) {
+					w.Write(fmt.Sprintf("&dd_test.notFound{key: %q}", err.Detail()))
+				}),
+				dumpError,
+			},
+			want: "[]error{\n  errors.New(\"a\"),\n  &dd_test.notFound{key: \"key: b\"},\n}",
+		},
+		{
+			name: "pointer to value receiver",
+			v: struct {
+				S  status
+				P  *status
+				NP *status
+			}{S: 1, P: new(status)},
+			opts: []dd.OptionFunc{
+				dd.WithDumpFunc(func(s fmt.Stringer, w dd.Writer) {
+					w.Write(fmt.Sprintf("parseStatus(%q)", s.String()))
+				}),
+			},
+			want: "struct { S dd_test.status; P *dd_test.status; NP *dd_test.status }{\n  S: parseStatus(\"status1\"),\n  P: func() *dd_test.status { v := parseStatus(\"status0\"); return &v }(),\n  NP: (*dd_test.status)(nil),\n}",
+		},
+		{
+			name: "exact type takes precedence",
+			v:    []fmt.Stringer{status(1)},
+			opts: []dd.OptionFunc{
+				dd.WithDumpFunc(func(s fmt.Stringer, w dd.Writer) {
+					w.Write("stringer")
+				}),
+				dd.WithDumpFunc(func(s status, w dd.Writer) {
+					w.Write("dd_test.status(1)")
+				}),
+			},
+			want: "[]fmt.Stringer{\n  dd_test.status(1),\n}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, tc.opts...)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+func TestWithDumpFuncPointer(t *testing.T) {
+	dumpPoint := dd.WithDumpFunc(func(p point, w dd.Writer) {
+		w.Write(fmt.Sprintf("dd_test.newPoint(%d, %d)", p.X, p.Y))
+	})
+	dumpPointPtr := dd.WithDumpFunc(func(p *point, w dd.Writer) {
+		w.Write(fmt.Sprintf("dd_test.newPointPtr(%d, %d)", p.X, p.Y))
+	})
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "value func for pointer",
+			v:    []*point{{X: 1, Y: 2}, nil},
+			opts: []dd.OptionFunc{dumpPoint},
+			want: "[]*dd_test.point{\n  func() *dd_test.point { v := dd_test.newPoint(1, 2); return &v }(),\n  (*dd_test.point)(nil),\n}",
+		},
+		{
+			name: "value func for pointer with helper",
+			v:    &point{X: 1, Y: 2},
+			opts: []dd.OptionFunc{dumpPoint, dd.WithPointerFormat(dd.HelperPointer)},
+			want: "ptr(dd_test.newPoint(1, 2))",
+		},
+		{
+			name: "pointer func for value",
+			v:    []point{{X: 1, Y: 2}},
+			opts: []dd.OptionFunc{dumpPointPtr},
+			want: "[]dd_test.point{\n  *dd_test.newPointPtr(1, 2),\n}",
+		},
+		{
+			name: "pointer func takes precedence",
+			v:    []interface{}{&point{X: 1, Y: 2}, point{X: 3, Y: 4}},
+			opts: []dd.OptionFunc{dumpPoint, dumpPointPtr},
+			want: "[]interface {}{\n  dd_test.newPointPtr(1, 2),\n  dd_test.newPoint(3, 4),\n}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, tc.opts...)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+type inventory struct {
+	items []point
+}
+
+func TestWriter(t *testing.T) {
+	var paths []string
+	var depths []int
+	dumpInventory := dd.WithDumpFunc(func(v inventory, w dd.Writer) {
+		paths = append(paths, w.Path())
+		depths = append(depths, w.Depth())
+		w.Write("dd_test.newInventory(dd_test.inventoryItems{")
+		w.Indent()
+		w.WriteField("Items", v.items)
+		w.WriteField("Count", len(v.items))
+		w.Dedent()
+		w.Write("})")
+	})
+	dumpPoint := dd.WithDumpFunc(func(p point, w dd.Writer) {
+		paths = append(paths, w.Path())
+		w.Write("dd_test.newPoint(")
+		w.WriteValue([]int{p.X, p.Y})
+		w.Write(")")
+	})
+	v := map[string]inventory{
+		"a": {items: []point{{X: 1, Y: 2}}},
+	}
+	got := dd.Dump(v, dumpInventory, dumpPoint, dd.WithUintFormat(dd.HexUint))
+	want := `map[string]dd_test.inventory{
+  "a": dd_test.newInventory(dd_test.inventoryItems{
+    Items: []dd_test.point{
+      dd_test.newPoint([]int{
+        1,
+        2,
+      }),
+    },
+    Count: 1,
+  }),
+}`
+	if want != got {
+		t.Fatalf("want %q, but got %q", want, got)
+	}
+	if _, err := parser.ParseExpr(got); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`["a"]`, `["a"].Items[0]`}; !reflect.DeepEqual(want, paths) {
+		t.Errorf("want paths %q, but got %q", want, paths)
+	}
+	if want := []int{1}; !reflect.DeepEqual(want, depths) {
+		t.Errorf("want depths %v, but got %v", want, depths)
+	}
+}
+
+func TestWriterOptions(t *testing.T) {
+	got := dd.Dump(point{X: 1}, dd.WithIndent(4), dd.WithDumpFunc(func(p point, w dd.Writer) {
+		// the options are passed to other dump functions.
+		w.Write(strconv.Quote(dd.Dump([]int{p.X}, w.Options()...)))
+	}))
+	want := strconv.Quote("[]int{\n    1,\n}")
+	if want != got {
+		t.Fatalf("want %q, but got %q", want, got)
+	}
+}
+
+func TestWithKindDumpFunc(t *testing.T) {
+	dumpHex := dd.WithKindDumpFunc(reflect.Array, func(rv reflect.Value, w dd.Writer) {
+		b := make([]byte, rv.Len())
+		reflect.Copy(reflect.ValueOf(b), rv)
+		w.Write(fmt.Sprintf("dd_test.hexArray(%q)", hex.EncodeToString(b)))
+	})
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "arrays of any length",
+			v: struct {
+				ID  [4]byte
+				Sum [2]byte
+			}{ID: [4]byte{0xde, 0xad, 0xbe, 0xef}, Sum: [2]byte{1, 2}},
+			opts: []dd.OptionFunc{dumpHex},
+			want: "struct { ID [4]uint8; Sum [2]uint8 }{\n  ID: dd_test.hexArray(\"deadbeef\"),\n  Sum: dd_test.hexArray(\"0102\"),\n}",
+		},
+		{
+			name: "pointer",
+			v:    []*[2]byte{{1, 2}, nil},
+			opts: []dd.OptionFunc{dumpHex},
+			want: "[]*[2]uint8{\n  func() *[2]uint8 { v := dd_test.hexArray(\"0102\"); return &v }(),\n  (*[2]uint8)(nil),\n}",
+		},
+		{
+			name: "Dumper takes precedence",
+			v:    celsius(1.5),
+			opts: []dd.OptionFunc{
+				dd.WithKindDumpFunc(reflect.Float64, func(rv reflect.Value, w dd.Writer) {
+					w.Write(fmt.Sprintf("dd_test.celsius(%d)", int(rv.Float())))
+				}),
+			},
+			want: "dd_test.celsius(1.5)",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, tc.opts...)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestWithMatchDumpFunc(t *testing.T) {
+	pkgPath := reflect.TypeOf(point{}).PkgPath()
+	inPackage := func(typ reflect.Type) bool {
+		return typ.PkgPath() == pkgPath
+	}
+	dumpNew := func(name string) func(reflect.Value, dd.Writer) {
+		return func(rv reflect.Value, w dd.Writer) {
+			w.Write(fmt.Sprintf("%s(%q)", name, rv.Type()))
+		}
+	}
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "types of package",
+			v:    []interface{}{point{}, status(1), &inventory{}, 1},
+			opts: []dd.OptionFunc{
+				dd.WithMatchDumpFunc(inPackage, dumpNew("dd_test.match")),
+			},
+			want: "[]interface {}{\n  dd_test.match(\"dd_test.point\"),\n  dd_test.match(\"dd_test.status\"),\n  func() *dd_test.inventory { v := dd_test.match(\"dd_test.inventory\"); return &v }(),\n  1,\n}",
+		},
+		{
+			name: "last registered one is used",
+			v:    point{},
+			opts: []dd.OptionFunc{
+				dd.WithMatchDumpFunc(inPackage, dumpNew("dd_test.first")),
+				dd.WithMatchDumpFunc(inPackage, dumpNew("dd_test.last")),
+			},
+			want: "dd_test.last(\"dd_test.point\")",
+		},
+		{
+			name: "precedence",
+			v:    []interface{}{point{}, inventory{}, celsius(1.5), struct{}{}},
+			opts: []dd.OptionFunc{
+				dd.WithKindDumpFunc(reflect.Struct, dumpNew("dd_test.kind")),
+				dd.WithMatchDumpFunc(inPackage, dumpNew("dd_test.match")),
+				dd.WithDumpFunc(func(p point, w dd.Writer) {
+					w.Write("dd_test.exact()")
+				}),
+			},
+			want: "[]interface {}{\n  dd_test.exact(),\n  dd_test.match(\"dd_test.inventory\"),\n  dd_test.celsius(1.5),\n  dd_test.kind(\"struct {}\"),\n}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, tc.opts...)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package dd
 
-import "reflect"
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+)
 
 type UintFormat int
 
@@ -15,15 +20,105 @@ const (
 	HexUint
 )
 
+// PointerFormat is a format to display the pointer whose address
+// can not be taken directly like &T{...}. e.g. *int, *string, **T
+type PointerFormat int
+
+const (
+	// InlinePointer is mode to display the pointer as an immediately invoked function.
+	// The format be like func() *int { v := 1; return &v }()
+	InlinePointer PointerFormat = iota
+	// HelperPointer is mode to display the pointer using a generic helper function.
+	// The format be like ptr(1). The helper function must be declared as follows:
+	//
+	//	func ptr[T any](v T) *T { return &v }
+	HelperPointer
+)
+
 // Dump dumps specified data.
 func Dump(data interface{}, opts ...OptionFunc) string {
-	return newDataDumper(data, opts...).build().String()
+	var buf strings.Builder
+	// writing to strings.Builder never returns an error.
+	newDataDumper(&buf, data, opts...).dumpRoot()
+	return buf.String()
+}
+
+// DumpStrict dumps specified data as same as Dump, but reports the error if
+// the output can not be compiled or can not reproduce the data.
+// e.g. unexported types or fields of other packages, channels, functions,
+// unsafe pointers and circular references.
+//
+// The returned error is *UnrepresentableError which has all paths to
+// such values. The dumped string is returned even if the error is returned.
+func DumpStrict(data interface{}, opts ...OptionFunc) (string, error) {
+	var buf strings.Builder
+	d := newDataDumper(&buf, data, opts...)
+	d.strict = true
+	d.dumpRoot()
+	if len(d.unrepresentable) > 0 {
+		return buf.String(), &UnrepresentableError{Values: d.unrepresentable}
+	}
+	return buf.String(), nil
+}
+
+// Fdump dumps specified data and writes to w.
+// The output is written while walking the data instead of building
+// the whole result in memory.
+// It returns the number of bytes written and any write error encountered.
+func Fdump(w io.Writer, data interface{}, opts ...OptionFunc) (int, error) {
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	d := newDataDumper(bw, data, opts...)
+	d.dumpRoot()
+	if d.err != nil {
+		return cw.n, d.err
+	}
+	err := bw.Flush()
+	return cw.n, err
 }
 
 // Writer is a writer for dump string.
+//
+// The nested values can be written with the options in effect like this:
+//
+//	dd.WithDumpFunc(func(v orders.List, w dd.Writer) {
+//		w.Write("orders.NewList(orders.List{")
+//		w.Indent()
+//		w.WriteField("Items", v.Items())
+//		w.Dedent()
+//		w.Write("})")
+//	})
 type Writer interface {
+	// Write writes s as is.
 	Write(s string)
+	// WriteBlock writes s in the braces with the indentation of each line.
 	WriteBlock(s string)
+	// WriteValue writes v in the same format as dd with the options in effect.
+	WriteValue(v interface{})
+	// WriteField writes the new line, and then writes the struct field
+	// like "name: v," at the current depth.
+	WriteField(name string, v interface{})
+	// Indent increases the depth of the indentation.
+	Indent()
+	// Dedent decreases the depth of the indentation, and then writes
+	// the new line at the depth.
+	Dedent()
+	// Path returns the path to the value being dumped from the root.
+	// e.g. .Items[2].Price
+	Path() string
+	// Depth returns the current depth of the indentation.
+	Depth() int
+	// Options returns the options in effect.
+	Options() []OptionFunc
+}
+
+// Dumper is the interface implemented by the types which dump themselves.
+// DumpDD writes the receiver to w as the expression of the receiver's type.
+// It is called instead of the default format, but the function registered by
+// WithDumpFunc for the type takes precedence. DumpDD takes precedence over
+// the functions registered by WithMatchDumpFunc and WithKindDumpFunc.
+type Dumper interface {
+	DumpDD(w Writer)
 }
 
 // OptionFunc is a function for making options.
@@ -53,6 +148,116 @@ func WithUintFormat(mode UintFormat) OptionFunc {
 	}
 }
 
+// WithPointerFormat specify mode to display the pointer whose address
+// can not be taken directly like &T{...}.
+// default is InlinePointer.
+func WithPointerFormat(mode PointerFormat) OptionFunc {
+	return func(o *options) {
+		o.ptrFormat = mode
+	}
+}
+
+// WithGraph enables graph-aware mode for pointers and maps.
+//
+// In this mode, the values referenced more than once (e.g. shared by two fields,
+// or cyclic references) are declared as variables, and cyclic references are
+// rebuilt with assignment statements. So the identity of these values is kept.
+// The output will be an immediately invoked function like this:
+//
+//	func() *main.Node {
+//	  v1 := &main.Node{
+//	    Next: (*main.Node)(nil),
+//	  }
+//	  v1.Next = v1
+//	  return v1
+//	}()
+//
+// If there are no such values, the output is the same as without this option.
+func WithGraph() OptionFunc {
+	return func(o *options) {
+		o.graph = true
+	}
+}
+
+// WithGoStringer enables to dump the values which implement fmt.GoStringer
+// using GoString method. The output of GoString is written as is,
+// so it must be valid Go syntax.
+// Dumper and the functions registered by the options like WithDumpFunc
+// take precedence.
+func WithGoStringer() OptionFunc {
+	return func(o *options) {
+		o.goStringer = true
+	}
+}
+
+// WithKindDumpFunc is an option to add function f for customize dump string
+// of the types of kind k, like the arrays of any length and element type.
+//
+//	dd.WithKindDumpFunc(reflect.Array, func(rv reflect.Value, w dd.Writer) {
+//		...
+//	})
+//
+// The functions registered by WithDumpFunc and WithMatchDumpFunc, and Dumper
+// take precedence.
+// As with WithDumpFunc, the pointer to the type is dumped as the pointer to
+// the value dumped by f, and the nil pointers are always dumped as nil.
+func WithKindDumpFunc(k reflect.Kind, f func(reflect.Value, Writer)) OptionFunc {
+	return func(o *options) {
+		o.kindDumpFuncs[k] = f
+	}
+}
+
+// WithMatchDumpFunc is an option to add function f for customize dump string
+// of the types which match reports true, like the types of a package.
+//
+//	dd.WithMatchDumpFunc(func(typ reflect.Type) bool {
+//		return typ.PkgPath() == "example.com/app/money"
+//	}, func(rv reflect.Value, w dd.Writer) {
+//		...
+//	})
+//
+// The functions registered by WithDumpFunc and Dumper take precedence, and f
+// takes precedence over the functions registered by WithKindDumpFunc. If the type
+// matches multiple predicates, the last registered one is used.
+// As with WithDumpFunc, the pointer to the type is dumped as the pointer to
+// the value dumped by f, and the nil pointers are always dumped as nil.
+func WithMatchDumpFunc(match func(reflect.Type) bool, f func(reflect.Value, Writer)) OptionFunc {
+	return func(o *options) {
+		o.matchDumpFuncs = append(o.matchDumpFuncs, matchDumpFunc{match: match, f: f})
+	}
+}
+
+// WithPackagePath specifies the import path of the package where the output
+// is written. The types belonging to the package are written without
+// the package name.
+//
+//	dd.Dump(orders.Item{}, dd.WithPackagePath("example.com/app/orders"))
+//	// Item{}
+func WithPackagePath(pkgPath string) OptionFunc {
+	return func(o *options) {
+		o.packagePath = pkgPath
+	}
+}
+
+// WithImports specifies the packages which are already imported by the file
+// where the output of DumpFile is written. imports is keyed by the import path,
+// and the value is the name of the import (empty if it is not renamed).
+//
+// The types of these packages are qualified by the names, and the other
+// packages are imported with the aliases if their names collide.
+//
+//	dd.DumpFile("example", "pod", pod, dd.WithImports(map[string]string{
+//		"k8s.io/api/core/v1": "corev1",
+//	}))
+//	// var pod = &corev1.Pod{...
+func WithImports(imports map[string]string) OptionFunc {
+	return func(o *options) {
+		for pkgPath, name := range imports {
+			o.fileImports[pkgPath] = name
+		}
+	}
+}
+
 // WithListBreakLineSize is an option to specify the number of elements to break lines
 // when dumped a listing (slice, array) of a given type.
 // The number must be more than 1 otherwise treats as 1.
@@ -1,8 +1,9 @@
 package dd_test
 
 import (
+	"bytes"
 	"context"
-	"fmt"
+	"errors"
 	"go/parser"
 	"math"
 	"mime/multipart"
@@ -15,6 +16,7 @@ import (
 	"unsafe"
 
 	"github.com/Code-Hex/dd"
+	v1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
 )
 
 const (
@@ -129,26 +131,82 @@ func TestDumpBasic(t *testing.T) {
 		{
 			name: "max float32",
 			v:    float32(math.MaxFloat32),
-			want: fmt.Sprintf("%f", float32(math.MaxFloat32)),
+			want: "3.4028235e+38",
 		},
 		{
 			name: "max float64",
 			v:    float64(math.MaxFloat64),
-			want: fmt.Sprintf("%f", float64(math.MaxFloat64)),
+			want: "1.7976931348623157e+308",
+		},
+		{
+			name: "float32 shortest",
+			v:    float32(0.1),
+			want: "0.1",
+		},
+		{
+			name: "float64 shortest",
+			v:    float64(0.1234567),
+			want: "0.1234567",
+		},
+		{
+			name: "float64 small",
+			v:    float64(1e-9),
+			want: "1e-09",
+		},
+		{
+			name: "float64 integral",
+			v:    float64(100000),
+			want: "100000.0",
+		},
+		{
+			name: "float64 NaN",
+			v:    math.NaN(),
+			want: "math.NaN()",
+		},
+		{
+			name: "float64 +Inf",
+			v:    math.Inf(1),
+			want: "math.Inf(1)",
+		},
+		{
+			name: "float64 -Inf",
+			v:    math.Inf(-1),
+			want: "math.Inf(-1)",
+		},
+		{
+			name: "float64 negative zero",
+			v:    math.Copysign(0, -1),
+			want: "math.Copysign(0, -1)",
+		},
+		{
+			name: "float32 NaN",
+			v:    float32(math.NaN()),
+			want: "float32(math.NaN())",
+		},
+		{
+			name: "float32 negative zero",
+			v:    float32(math.Copysign(0, -1)),
+			want: "float32(math.Copysign(0, -1))",
 		},
 		{
 			name: "max complex64",
 			v:    complex64(complex(float32(math.MaxFloat32), float32(math.MaxFloat32))),
-			want: fmt.Sprintf("%v",
-				complex64(complex(float32(math.MaxFloat32), float32(math.MaxFloat32))),
-			),
+			want: "complex(3.4028235e+38, 3.4028235e+38)",
 		},
 		{
 			name: "max complex128",
 			v:    complex128(complex(float64(math.MaxFloat64), float64(math.MaxFloat64))),
-			want: fmt.Sprintf("%v",
-				complex128(complex(float64(math.MaxFloat64), float64(math.MaxFloat64))),
-			),
+			want: "complex(1.7976931348623157e+308, 1.7976931348623157e+308)",
+		},
+		{
+			name: "complex128 NaN and Inf",
+			v:    complex(math.NaN(), math.Inf(-1)),
+			want: "complex(math.NaN(), math.Inf(-1))",
+		},
+		{
+			name: "complex64 NaN",
+			v:    complex(float32(math.NaN()), 1),
+			want: "complex(float32(math.NaN()), 1.0)",
 		},
 		{
 			name: "array [0]int{}",
@@ -333,6 +391,156 @@ func TestDumpBasic(t *testing.T) {
 
 }
 
+func TestFloatRoundTrip(t *testing.T) {
+	values := []float64{
+		0, 1, -1, 0.1, 0.1234567, 1e-9, 1e21, 123456789.123456789,
+		math.Pi, math.E, math.SmallestNonzeroFloat64, math.MaxFloat64,
+		math.SmallestNonzeroFloat32, math.MaxFloat32,
+	}
+	for _, f := range values {
+		got := dd.Dump(f)
+		parsed, err := strconv.ParseFloat(got, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Float64bits(f) != math.Float64bits(parsed) {
+			t.Errorf("float64: want %v, but got %q", f, got)
+		}
+
+		f32 := float32(f)
+		if math.IsInf(float64(f32), 0) {
+			continue // overflowed
+		}
+		got = dd.Dump(f32)
+		parsed, err = strconv.ParseFloat(got, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Float32bits(f32) != math.Float32bits(float32(parsed)) {
+			t.Errorf("float32: want %v, but got %q", f32, got)
+		}
+	}
+}
+
+type myComplex complex64
+
+type (
+	myStatus int
+	myBool   bool
+	myString string
+	myFloat  float64
+)
+
+func TestInterfaceConversion(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "default types",
+			v:    []interface{}{1, "a", true, 1.5},
+			want: "[]interface {}{\n  1,\n  \"a\",\n  true,\n  1.5,\n}",
+		},
+		{
+			name: "sized numbers",
+			v:    []interface{}{int8(1), uint(2), float32(3), uintptr(4), 'a'},
+			want: "[]interface {}{\n  int8(1),\n  uint(2),\n  float32(3.0),\n  uintptr(4),\n  int32(97),\n}",
+		},
+		{
+			name: "named types",
+			v:    []interface{}{myStatus(2), myBool(true), myString("s"), myFloat(0.5)},
+			want: "[]interface {}{\n  dd_test.myStatus(2),\n  dd_test.myBool(true),\n  dd_test.myString(\"s\"),\n  dd_test.myFloat(0.5),\n}",
+		},
+		{
+			name: "map values",
+			v:    map[string]interface{}{"a": int64(1)},
+			want: "map[string]interface {}{\n  \"a\": int64(1),\n}",
+		},
+		{
+			name: "map keys",
+			v:    map[interface{}]int{uint8(1): 1},
+			want: "map[interface {}]int{\n  uint8(1): 1,\n}",
+		},
+		{
+			name: "typed context",
+			v:    []myStatus{1, 2},
+			want: "[]dd_test.myStatus{\n  1,\n  2,\n}",
+		},
+		{
+			name: "special float",
+			v:    []interface{}{float32(math.NaN())},
+			want: "[]interface {}{\n  float32(math.NaN()),\n}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	t.Run("uint format", func(t *testing.T) {
+		got := dd.Dump([]interface{}{uint8(1)}, dd.WithUintFormat(dd.HexUint))
+		want := "[]interface {}{\n  uint8(0x01),\n}"
+		if want != got {
+			t.Fatalf("want %q, but got %q", want, got)
+		}
+	})
+}
+
+func TestComplexNumber(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "complex64 in slice",
+			v:    []complex64{complex(1, 2)},
+			want: "[]complex64{\n  complex(1.0, 2.0),\n}",
+		},
+		{
+			name: "complex128 in interface",
+			v:    []interface{}{complex(1, 2)},
+			want: "[]interface {}{\n  complex(1.0, 2.0),\n}",
+		},
+		{
+			name: "complex64 in interface",
+			v:    []interface{}{complex64(complex(1, 2))},
+			want: "[]interface {}{\n  complex64(complex(1.0, 2.0)),\n}",
+		},
+		{
+			name: "complex64 NaN in interface",
+			v:    []interface{}{complex64(complex(float32(math.NaN()), 2))},
+			want: "[]interface {}{\n  complex(float32(math.NaN()), 2.0),\n}",
+		},
+		{
+			name: "named complex NaN",
+			v:    []myComplex{myComplex(complex(float32(math.Inf(1)), 0))},
+			want: "[]dd_test.myComplex{\n  dd_test.myComplex(complex(float32(math.Inf(1)), 0.0)),\n}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestPointer(t *testing.T) {
 	cases := []struct {
 		name string
@@ -352,22 +560,43 @@ func TestPointer(t *testing.T) {
 		{
 			name: "pointer of int",
 			v:    new(int),
-			want: "(*int)(unsafe.Pointer(uintptr(",
+			want: "func() *int { v := 0; return &v }()",
 		},
 		{
 			name: "pointer of string",
 			v:    new(string),
-			want: "(*string)(unsafe.Pointer(uintptr(",
+			want: "func() *string { v := \"\"; return &v }()",
 		},
 		{
 			name: "pointer of bool",
 			v:    new(bool),
-			want: "(*bool)(unsafe.Pointer(uintptr(",
+			want: "func() *bool { v := false; return &v }()",
 		},
 		{
 			name: "pointer of uint8",
 			v:    new(uint8),
-			want: "(*uint8)(unsafe.Pointer(uintptr(",
+			want: "func() *uint8 { v := uint8(0); return &v }()",
+		},
+		{
+			name: "pointer of named type",
+			v: func() interface{} {
+				v := myStatus(2)
+				return &v
+			}(),
+			want: "func() *dd_test.myStatus { v := dd_test.myStatus(2); return &v }()",
+		},
+		{
+			name: "pointer of interface",
+			v: func() interface{} {
+				var v interface{} = 1
+				return &v
+			}(),
+			want: "func() *interface {} { var v interface {} = 1; return &v }()",
+		},
+		{
+			name: "pointer of nil slice",
+			v:    new([]int),
+			want: "func() *[]int { v := ([]int)(nil); return &v }()",
 		},
 		{
 			name: "pointer of struct",
@@ -380,7 +609,15 @@ func TestPointer(t *testing.T) {
 				a := &struct{ age int }{age: 10}
 				return &a
 			}(),
-			want: "(**struct { age int })(unsafe.Pointer(uintptr(",
+			want: "func() **struct { age int } { v := &struct { age int }{\n  age: 10,\n}; return &v }()",
+		},
+		{
+			name: "pointer of pointer of int",
+			v: func() interface{} {
+				a := new(int)
+				return &a
+			}(),
+			want: "func() **int { v := func() *int { v := 0; return &v }(); return &v }()",
 		},
 		{
 			name: "pointer of slice",
@@ -434,6 +671,78 @@ func TestPointer(t *testing.T) {
 	})
 }
 
+type errWriter struct {
+	n   int
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	if len(p) <= e.n {
+		e.n -= len(p)
+		return len(p), nil
+	}
+	n := e.n
+	e.n = 0
+	return n, e.err
+}
+
+func TestFdump(t *testing.T) {
+	v := map[string]interface{}{
+		"a": []int{1, 2},
+		"bb": map[string]int{
+			"c": 3,
+		},
+		"ccc": struct{ age int }{age: 10},
+	}
+	t.Run("same as Dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		n, err := dd.Fdump(&buf, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := dd.Dump(v)
+		if got := buf.String(); want != got {
+			t.Fatalf("want %q, but got %q", want, got)
+		}
+		if n != len(want) {
+			t.Fatalf("want %d bytes, but got %d", len(want), n)
+		}
+	})
+	t.Run("write error", func(t *testing.T) {
+		wantErr := errors.New("write error")
+		w := &errWriter{n: 10, err: wantErr}
+		n, err := dd.Fdump(w, v)
+		if !errors.Is(err, wantErr) {
+			t.Fatalf("want %v, but got %v", wantErr, err)
+		}
+		if n != 10 {
+			t.Fatalf("want 10 bytes, but got %d", n)
+		}
+	})
+}
+
+func TestWithPointerFormat(t *testing.T) {
+	type optional struct {
+		Name  *string
+		Count **int64
+		Any   *interface{}
+	}
+	name := "dd"
+	count := int64(1)
+	countPtr := &count
+	var any interface{} = true
+	v := optional{Name: &name, Count: &countPtr, Any: &any}
+
+	got := dd.Dump(v, dd.WithPointerFormat(dd.HelperPointer))
+	want := "dd_test.optional{\n  Name: ptr(\"dd\"),\n  Count: ptr(ptr(int64(1))),\n  Any: ptr[interface {}](true),\n}"
+	if want != got {
+		t.Fatalf("want %q, but got %q", want, got)
+	}
+	if _, err := parser.ParseExpr(got); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestWithIndent(t *testing.T) {
 	want := "[]int{\n    1,\n    2,\n}"
 	got := dd.Dump([]int{1, 2}, dd.WithIndent(4))
@@ -462,6 +771,51 @@ func TestWithExportedOnly(t *testing.T) {
 	}
 }
 
+type orderItem struct {
+	Name string
+}
+
+type order struct {
+	Items   []orderItem
+	Primary *orderItem
+	Header  textproto.MIMEHeader
+	Find    func(string) (orderItem, error)
+	Any     interface{}
+}
+
+func TestWithPackagePath(t *testing.T) {
+	v := order{
+		Items: []orderItem{{Name: "book"}},
+		Find:  func(string) (orderItem, error) { return orderItem{}, nil },
+		Any:   []*orderItem{nil},
+	}
+	got := dd.Dump(v, dd.WithPackagePath("github.com/Code-Hex/dd_test"))
+	want := `order{
+  Items: []orderItem{
+    orderItem{
+      Name: "book",
+    },
+  },
+  Primary: (*orderItem)(nil),
+  Header: (textproto.MIMEHeader)(nil),
+  Find: func(string) (orderItem, error) {
+    // ...
+    return orderItem{
+      Name: "",
+    }, nil
+  },
+  Any: []*orderItem{
+    (*orderItem)(nil),
+  },
+}`
+	if want != got {
+		t.Fatalf("want %q, but got %q", want, got)
+	}
+	if _, err := parser.ParseExpr(got); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestWithUintFormat(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -583,6 +937,182 @@ func TestCircularRefs(t *testing.T) {
 	}
 }
 
+type listNode struct {
+	Val        int
+	Prev, Next *listNode
+}
+
+// anyHolder holds the value whose type is known only at run time.
+type anyHolder struct {
+	X interface{}
+}
+
+func TestWithGraph(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{
+			name: "doubly linked list",
+			v: func() interface{} {
+				a := &listNode{Val: 1}
+				a.Next = &listNode{Val: 2, Prev: a}
+				return a
+			}(),
+			want: `func() *dd_test.listNode {
+  v1 := &dd_test.listNode{
+    Val: 1,
+    Prev: (*dd_test.listNode)(nil),
+    Next: &dd_test.listNode{
+      Val: 2,
+      Prev: (*dd_test.listNode)(nil),
+      Next: (*dd_test.listNode)(nil),
+    },
+  }
+  v1.Next.Prev = v1
+  return v1
+}()`,
+		},
+		{
+			name: "shared pointer",
+			v: func() interface{} {
+				s := "shared"
+				return struct{ A, B *string }{A: &s, B: &s}
+			}(),
+			want: `func() struct { A *string; B *string } {
+  v1 := func() *string { v := "shared"; return &v }()
+  return struct { A *string; B *string }{
+    A: v1,
+    B: v1,
+  }
+}()`,
+		},
+		{
+			name: "cyclic map through interface",
+			v: func() interface{} {
+				m := map[string]interface{}{}
+				m["self"] = m
+				return m
+			}(),
+			want: `func() map[string]interface {} {
+  v1 := map[string]interface {}{
+    "self": (map[string]interface {})(nil),
+  }
+  v1["self"] = v1
+  return v1
+}()`,
+		},
+		{
+			name: "variable names avoid package names",
+			v: func() interface{} {
+				p := &v1.Pod{Name: "a"}
+				return []*v1.Pod{p, p}
+			}(),
+			want: `func() []*v1.Pod {
+  v2 := &v1.Pod{
+    Name: "a",
+    Meta: v1.Meta{
+      Labels: (map[string]string)(nil),
+    },
+  }
+  return []*v1.Pod{
+    v2,
+    v2,
+  }
+}()`,
+		},
+		{
+			name: "variable names avoid package names in interfaces",
+			v: func() interface{} {
+				a, b := &anyHolder{X: v1.Meta{}}, &anyHolder{X: v1.Meta{}}
+				return []interface{}{a, a, b, b}
+			}(),
+			want: `func() []interface {} {
+  v2 := &dd_test.anyHolder{
+    X: v1.Meta{
+      Labels: (map[string]string)(nil),
+    },
+  }
+  v3 := &dd_test.anyHolder{
+    X: v1.Meta{
+      Labels: (map[string]string)(nil),
+    },
+  }
+  return []interface {}{
+    v2,
+    v2,
+    v3,
+    v3,
+  }
+}()`,
+		},
+		{
+			name: "no shared values",
+			v:    &listNode{Val: 1},
+			want: "&dd_test.listNode{\n  Val: 1,\n  Prev: (*dd_test.listNode)(nil),\n  Next: (*dd_test.listNode)(nil),\n}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, dd.WithGraph())
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestWithGraphMapOrder(t *testing.T) {
+	a, b, c := &listNode{Val: 1}, &listNode{Val: 2}, &listNode{Val: 3}
+	v := map[string][]*listNode{
+		"x": {c, a},
+		"y": {b, c},
+		"z": {a, b},
+	}
+	want := `func() map[string][]*dd_test.listNode {
+  v1 := &dd_test.listNode{
+    Val: 3,
+    Prev: (*dd_test.listNode)(nil),
+    Next: (*dd_test.listNode)(nil),
+  }
+  v2 := &dd_test.listNode{
+    Val: 1,
+    Prev: (*dd_test.listNode)(nil),
+    Next: (*dd_test.listNode)(nil),
+  }
+  v3 := &dd_test.listNode{
+    Val: 2,
+    Prev: (*dd_test.listNode)(nil),
+    Next: (*dd_test.listNode)(nil),
+  }
+  return map[string][]*dd_test.listNode{
+    "x": []*dd_test.listNode{
+      v1,
+      v2,
+    },
+    "y": []*dd_test.listNode{
+      v3,
+      v1,
+    },
+    "z": []*dd_test.listNode{
+      v2,
+      v3,
+    },
+  }
+}()`
+	// the map is iterated in random order.
+	for i := 0; i < 20; i++ {
+		if got := dd.Dump(v, dd.WithGraph()); want != got {
+			t.Fatalf("want %q, but got %q", want, got)
+		}
+	}
+}
+
 func TestWithListBreakLineSize(t *testing.T) {
 	cases := []struct {
 		name       string
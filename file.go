@@ -0,0 +1,226 @@
+package dd
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ptrHelper is the declaration of the helper function used by HelperPointer.
+const ptrHelper = "func ptr[T any](v T) *T { return &v }"
+
+// DumpFile dumps specified data as the Go source file which declares
+// the variable named varName in package pkgName.
+//
+// The import declarations are generated from the packages of types which are
+// written in the dumped data. If the names of these packages collide,
+// the packages are imported with the generated aliases. The nil data is
+// declared as interface{}(nil) so that the type of the variable is inferred.
+// The returned source is formatted by gofmt.
+func DumpFile(pkgName, varName string, data interface{}, opts ...OptionFunc) ([]byte, error) {
+	d := newDataDumper(nil, data, opts...)
+	d.initImports(varName)
+	return d.dumpFile(pkgName, varName, nil)
+}
+
+// dumpFile dumps the value as the Go source file. typeDecls are written
+// before the variable declaration.
+func (d *dumper) dumpFile(pkgName, varName string, typeDecls []string) ([]byte, error) {
+	var value strings.Builder
+	d.w = &value
+	// the type of the variable is inferred from the value.
+	d.needType = true
+	d.dumpRoot()
+
+	var decls strings.Builder
+	for _, decl := range typeDecls {
+		fmt.Fprintf(&decls, "%s\n\n", decl)
+	}
+	fmt.Fprintf(&decls, "var %s = %s\n", varName, value.String())
+	if d.usedPtrHelper {
+		fmt.Fprintf(&decls, "\n%s\n", ptrHelper)
+	}
+
+	// the custom dump functions may refer the packages which are not recorded.
+	// so the import declarations are generated only for the referred packages.
+	header := fmt.Sprintf("package %s\n\n", pkgName)
+	f, err := parser.ParseFile(token.NewFileSet(), "", header+decls.String(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse dumped data: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	switch specs := d.imports.specs(referredPackages(f)); len(specs) {
+	case 0:
+	case 1:
+		buf.WriteString("import " + specs[0] + "\n\n")
+	default:
+		buf.WriteString("import (\n")
+		for _, spec := range specs {
+			buf.WriteString(spec + "\n")
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.WriteString(decls.String())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format dumped data: %w", err)
+	}
+	return src, nil
+}
+
+// referredPackages returns the unresolved identifiers which are used as
+// the package name of qualified identifiers.
+func referredPackages(f *ast.File) map[string]bool {
+	ret := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+			ret[ident.Name] = true
+		}
+		return true
+	})
+	return ret
+}
+
+// imports records the packages to be imported.
+type imports struct {
+	names    map[string]string // package path to name
+	paths    map[string]string // name to package path
+	reserved map[string]bool
+	// imported is the packages imported without the alias by WithImports.
+	// The names are recorded when the types of the packages are written.
+	imported map[string]bool
+}
+
+func newImports(reserved ...string) *imports {
+	im := &imports{
+		names:    make(map[string]string),
+		paths:    make(map[string]string),
+		reserved: map[string]bool{"ptr": true},
+		imported: make(map[string]bool),
+	}
+	for _, name := range reserved {
+		im.reserved[name] = true
+	}
+	return im
+}
+
+// initImports starts recording the imports. The packages specified by
+// WithImports are recorded first.
+func (d *dumper) initImports(reserved ...string) {
+	d.imports = newImports(reserved...)
+	for pkgPath, name := range d.fileImports {
+		if name == "" {
+			d.imports.imported[pkgPath] = true
+			continue
+		}
+		d.imports.names[pkgPath] = name
+		d.imports.paths[name] = pkgPath
+	}
+}
+
+// qualify records the package and returns its name.
+// If the name is used by another package, the alias is generated.
+func (im *imports) qualify(pkgPath, pkgName string) string {
+	if name, ok := im.names[pkgPath]; ok {
+		return name
+	}
+	if im.imported[pkgPath] {
+		im.names[pkgPath] = pkgName
+		im.paths[pkgName] = pkgPath
+		return pkgName
+	}
+	name := pkgName
+	if im.used(name) {
+		// e.g. k8s.io/api/core/v1 => corev1
+		base := importAlias(pkgPath)
+		name = base
+		for i := 2; im.used(name); i++ {
+			name = base + strconv.Itoa(i)
+		}
+	}
+	im.names[pkgPath] = name
+	im.paths[name] = pkgPath
+	return name
+}
+
+// addCandidate records the package which may be referred by the custom dump
+// functions. It is recorded only if the name is not used.
+func (im *imports) addCandidate(pkgPath, pkgName string) {
+	if _, ok := im.names[pkgPath]; ok || !im.imported[pkgPath] && im.used(pkgName) {
+		return
+	}
+	im.names[pkgPath] = pkgName
+	im.paths[pkgName] = pkgPath
+}
+
+func (im *imports) used(name string) bool {
+	if _, ok := im.paths[name]; ok || im.reserved[name] {
+		return true
+	}
+	// the names of the packages imported without the alias are guessed
+	// until their types are written.
+	for pkgPath := range im.imported {
+		if _, ok := im.names[pkgPath]; !ok && packageNameOf(pkgPath) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// specs returns the import specs of the referred packages sorted by the path.
+func (im *imports) specs(referred map[string]bool) []string {
+	pkgPaths := make([]string, 0, len(im.names))
+	for pkgPath, name := range im.names {
+		// the main package can not be imported.
+		if referred[name] && pkgPath != "main" {
+			pkgPaths = append(pkgPaths, pkgPath)
+		}
+	}
+	sort.Strings(pkgPaths)
+	ret := make([]string, len(pkgPaths))
+	for i, pkgPath := range pkgPaths {
+		// the alias is always written if the name is not the last element
+		// of the path, because the package name may be different from it.
+		// e.g. gopkg.in/yaml.v3
+		name := im.names[pkgPath]
+		if name == path.Base(pkgPath) {
+			ret[i] = strconv.Quote(pkgPath)
+		} else {
+			ret[i] = name + " " + strconv.Quote(pkgPath)
+		}
+	}
+	return ret
+}
+
+// importAlias generates the alias from the last two elements of the path.
+func importAlias(pkgPath string) string {
+	elems := strings.Split(pkgPath, "/")
+	if len(elems) > 2 {
+		elems = elems[len(elems)-2:]
+	}
+	var buf strings.Builder
+	for _, r := range strings.Join(elems, "") {
+		isLetter := r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z'
+		isDigit := '0' <= r && r <= '9'
+		if isLetter || isDigit && buf.Len() > 0 {
+			buf.WriteRune(r)
+		}
+	}
+	if buf.Len() == 0 {
+		return "pkg"
+	}
+	return buf.String()
+}
@@ -0,0 +1,191 @@
+package dd_test
+
+import (
+	htemplate "html/template"
+	"math"
+	"testing"
+	ttemplate "text/template"
+	"time"
+
+	"github.com/Code-Hex/dd"
+	v1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDumpFile(t *testing.T) {
+	type templates struct {
+		Text *ttemplate.Template
+		HTML *htemplate.Template
+	}
+	n := 10
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "typed root",
+			v:    int64(1),
+			want: "package example\n\nvar data = int64(1)\n",
+		},
+		{
+			name: "nil root",
+			v:    nil,
+			want: "package example\n\nvar data = interface{}(nil)\n",
+		},
+		{
+			name: "imports",
+			v: map[string]interface{}{
+				"nan":     float32(math.NaN()),
+				"timeout": time.Second,
+			},
+			want: `package example
+
+import (
+	"math"
+	"time"
+)
+
+var data = map[string]interface{}{
+	"nan":     float32(math.NaN()),
+	"timeout": time.Duration(1000000000),
+}
+`,
+		},
+		{
+			name: "collided package names",
+			v:    []interface{}{(*ttemplate.Template)(nil), (*htemplate.Template)(nil)},
+			want: `package example
+
+import (
+	htmltemplate "html/template"
+	"text/template"
+)
+
+var data = []interface{}{
+	(*template.Template)(nil),
+	(*htmltemplate.Template)(nil),
+}
+`,
+		},
+		{
+			name: "ptr helper",
+			v:    []*int{&n},
+			opts: []dd.OptionFunc{dd.WithPointerFormat(dd.HelperPointer)},
+			want: `package example
+
+var data = []*int{
+	ptr(10),
+}
+
+func ptr[T any](v T) *T { return &v }
+`,
+		},
+		{
+			name: "graph with v1 package",
+			v: func() interface{} {
+				a, b := &v1.Pod{Name: "a"}, &v1.Pod{Name: "b"}
+				return []*v1.Pod{a, b, a, b}
+			}(),
+			opts: []dd.OptionFunc{dd.WithGraph()},
+			want: `package example
+
+import corev1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+
+var data = func() []*corev1.Pod {
+	v1 := &corev1.Pod{
+		Name: "a",
+		Meta: corev1.Meta{
+			Labels: (map[string]string)(nil),
+		},
+	}
+	v2 := &corev1.Pod{
+		Name: "b",
+		Meta: corev1.Meta{
+			Labels: (map[string]string)(nil),
+		},
+	}
+	return []*corev1.Pod{
+		v1,
+		v2,
+		v1,
+		v2,
+	}
+}()
+`,
+		},
+		{
+			name: "imports of the file",
+			v:    []interface{}{&v1.Pod{Name: "a"}, (*htemplate.Template)(nil)},
+			opts: []dd.OptionFunc{dd.WithImports(map[string]string{
+				"github.com/Code-Hex/dd/internal/testpkg/core/v1": "corev1",
+				"text/template": "",
+			})},
+			want: `package example
+
+import (
+	corev1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+	htmltemplate "html/template"
+)
+
+var data = []interface{}{
+	&corev1.Pod{
+		Name: "a",
+		Meta: corev1.Meta{
+			Labels: (map[string]string)(nil),
+		},
+	},
+	(*htmltemplate.Template)(nil),
+}
+`,
+		},
+		{
+			name: "current package",
+			v:    []*templates{nil},
+			opts: []dd.OptionFunc{dd.WithPackagePath("github.com/Code-Hex/dd_test")},
+			want: "package example\n\nvar data = []*templates{\n\t(*templates)(nil),\n}\n",
+		},
+		{
+			name: "unused helper",
+			v:    []*int{nil},
+			opts: []dd.OptionFunc{dd.WithPointerFormat(dd.HelperPointer)},
+			want: "package example\n\nvar data = []*int{\n\t(*int)(nil),\n}\n",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := dd.DumpFile("example", "data", tc.v, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Fatalf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("alias avoids the variable name", func(t *testing.T) {
+		got, err := dd.DumpFile("example", "template", templates{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `package example
+
+import (
+	"github.com/Code-Hex/dd_test"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+var template = dd_test.templates{
+	Text: (*texttemplate.Template)(nil),
+	HTML: (*htmltemplate.Template)(nil),
+}
+`
+		if diff := cmp.Diff(want, string(got)); diff != "" {
+			t.Fatalf("(-want, +got)\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,376 @@
+package dd
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Hex/dd/internal/sort"
+)
+
+// graph is the state of graph-aware mode.
+//
+// Pointers and maps are treated as nodes of the graph. The nodes referenced
+// more than once are declared as variables before the root value is written.
+type graph struct {
+	// refs is the number of references to each node.
+	refs map[nodeKey]int
+	// names is the variable names of the declared nodes.
+	names map[nodeKey]string
+	// varNames is the variable names to be used in order of declaration.
+	varNames []string
+	// dynamicTypes is the types of the values held in the interfaces.
+	dynamicTypes map[reflect.Type]bool
+	// inProgress records the nodes whose declarations are not completed yet.
+	inProgress map[nodeKey]bool
+	// owner is the node declared by the statement being written.
+	owner        nodeKey
+	ownerStarted bool
+	// fixups is the references to the nodes which were written before
+	// their declarations are completed.
+	fixups []fixup
+}
+
+func newGraph() *graph {
+	return &graph{
+		refs:         make(map[nodeKey]int),
+		names:        make(map[nodeKey]string),
+		inProgress:   make(map[nodeKey]bool),
+		dynamicTypes: make(map[reflect.Type]bool),
+	}
+}
+
+// nodeKey identifies the node. The type is needed because the pointer to
+// a struct and the pointer to its first field have the same address.
+type nodeKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// fixup is an assignment statement to rebuild the reference to target
+// from the value of owner.
+type fixup struct {
+	owner  nodeKey
+	path   []pathElem
+	target nodeKey
+}
+
+// nodeOf returns the key if v is a node of the graph.
+func nodeOf(v reflect.Value) (nodeKey, bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map:
+		if !v.IsNil() {
+			return nodeKey{ptr: v.Pointer(), typ: v.Type()}, true
+		}
+	}
+	return nodeKey{}, false
+}
+
+// writeGraph writes the root value with the declarations of the shared nodes.
+// It reports false without writing anything if there are no shared nodes.
+func (d *dumper) writeGraph() bool {
+	g := d.graph
+	root := d.value
+	if !root.IsValid() {
+		return false
+	}
+	d.walkRefs(root, func(key nodeKey, _ reflect.Value) bool {
+		g.refs[key]++
+		return g.refs[key] == 1
+	})
+	if !g.hasShared() {
+		return false
+	}
+	d.reserveVarNames(root)
+
+	d.printf("func() %s ", d.typeString(root.Type()))
+	d.writeBlock(func() {
+		rootKey, isNode := nodeOf(root)
+		if isNode && g.refs[rootKey] > 1 {
+			d.declareNode(rootKey, root)
+		} else {
+			d.walkRefs(root, d.declareShared)
+		}
+		for _, f := range g.fixups {
+			d.writeIndentedRaw(d.fixupExpr(f) + " = " + g.names[f.target] + "\n")
+		}
+
+		g.owner = nodeKey{}
+		d.path = d.path[:0]
+		d.writeIndentedRaw("return ")
+		if name, ok := g.names[rootKey]; isNode && ok {
+			d.writeRaw(name)
+		} else {
+			d.build()
+		}
+		d.writeRaw("\n")
+	})
+	d.writeRaw("()")
+	return true
+}
+
+func (g *graph) hasShared() bool {
+	for _, n := range g.refs {
+		if n > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// declareShared is a visitor for walkRefs to declare the shared nodes which
+// are not declared yet. The nodes referenced only once are walked into
+// because they will be written inline.
+func (d *dumper) declareShared(key nodeKey, v reflect.Value) bool {
+	g := d.graph
+	if g.refs[key] < 2 {
+		return true
+	}
+	if _, ok := g.names[key]; ok || g.inProgress[key] {
+		return false
+	}
+	d.declareNode(key, v)
+	return false
+}
+
+// declareNode writes the declaration of the node like "v1 := &T{...}".
+// The shared nodes referenced from the node are declared before that.
+func (d *dumper) declareNode(key nodeKey, v reflect.Value) {
+	g := d.graph
+	g.inProgress[key] = true
+	d.walkChildren(v, d.declareShared)
+
+	g.owner, g.ownerStarted = key, false
+	d.path = d.path[:0]
+	name := g.varNames[len(g.names)]
+	d.writeIndentedRaw(name + " := ")
+	d.dump(v)
+	d.writeRaw("\n")
+
+	delete(g.inProgress, key)
+	g.names[key] = name
+}
+
+// reserveVarNames prepares the variable names of the shared nodes which
+// do not collide with the package names. e.g. "v1" of k8s.io/api/core/v1
+//
+// If the imports are recorded, the names are reserved and the packages
+// are imported with the aliases instead. Otherwise, the names of the packages
+// of the types reachable from the root, the nodes and the values held in
+// the interfaces are avoided.
+func (d *dumper) reserveVarNames(root reflect.Value) {
+	g := d.graph
+	pkgNames := make(map[string]bool)
+	if d.imports == nil {
+		seen := make(map[reflect.Type]bool)
+		addName := func(typ reflect.Type) {
+			if typ.PkgPath() != "" {
+				s := typ.String()
+				pkgNames[s[:strings.IndexByte(s, '.')]] = true
+			}
+		}
+		walkTypes(root.Type(), seen, addName)
+		for key := range g.refs {
+			walkTypes(key.typ, seen, addName)
+		}
+		for typ := range g.dynamicTypes {
+			walkTypes(typ, seen, addName)
+		}
+	}
+	taken := func(name string) bool {
+		return pkgNames[name] || d.imports != nil && d.imports.used(name)
+	}
+	next := 1
+	for _, n := range g.refs {
+		if n < 2 {
+			continue
+		}
+		name := "v" + strconv.Itoa(next)
+		for ; taken(name); name = "v" + strconv.Itoa(next) {
+			next++
+		}
+		next++
+		g.varNames = append(g.varNames, name)
+		if d.imports != nil {
+			d.imports.reserved[name] = true
+		}
+	}
+}
+
+// writeGraphRef writes the reference to the shared node instead of the value
+// in graph-aware mode. It reports whether the reference was written.
+func (d *dumper) writeGraphRef() bool {
+	g := d.graph
+	if g == nil {
+		return false
+	}
+	key := nodeKey{ptr: d.value.Pointer(), typ: d.value.Type()}
+	if g.refs[key] < 2 {
+		return false
+	}
+	if key == g.owner && !g.ownerStarted {
+		g.ownerStarted = true
+		return false
+	}
+	if name, ok := g.names[key]; ok {
+		d.writeRaw(name)
+		return true
+	}
+	// The node is referenced before its declaration is completed (e.g. cyclic reference).
+	// If the reference can not be rebuilt by an assignment, the value is written
+	// as same as without graph-aware mode.
+	if !g.inProgress[key] || g.owner.typ == nil || !isAssignablePath(d.path) {
+		return false
+	}
+	path := make([]pathElem, len(d.path))
+	copy(path, d.path)
+	g.fixups = append(g.fixups, fixup{
+		owner:  g.owner,
+		path:   path,
+		target: key,
+	})
+	d.printf("(%s)(nil)", d.typeString(d.value.Type()))
+	return true
+}
+
+// isAssignablePath reports whether the value at the path from a variable
+// can be the left-hand side of an assignment.
+func isAssignablePath(path []pathElem) bool {
+	addressable, mapIndex := true, false
+	for i, elem := range path {
+		last := i == len(path)-1
+		switch elem.kind {
+		case derefPath:
+			addressable, mapIndex = true, false
+		case fieldPath:
+			mapIndex = false
+		case indexPath:
+			if elem.typ.Kind() == reflect.Slice {
+				addressable = true
+			}
+			mapIndex = false
+		case mapKeyPath:
+			// m[k] is assignable but not addressable.
+			addressable, mapIndex = false, true
+		case interfacePath:
+			if last {
+				return addressable || mapIndex
+			}
+			// the value held in the interface is accessible only
+			// through the type assertion to the pointer.
+			if path[i+1].kind != derefPath {
+				return false
+			}
+		}
+	}
+	return addressable || mapIndex
+}
+
+// fixupExpr returns the left-hand side expression of the fixup.
+func (d *dumper) fixupExpr(f fixup) string {
+	expr := d.graph.names[f.owner]
+	var derefs []reflect.Type
+	for i, elem := range f.path {
+		switch elem.kind {
+		case derefPath:
+			derefs = append(derefs, elem.typ)
+		case fieldPath:
+			// the selector dereferences the pointer to struct automatically.
+			expr = derefExpr(expr, len(derefs)-1) + "." + elem.name
+			derefs = derefs[:0]
+		case indexPath, mapKeyPath:
+			// the index expression dereferences the pointer to array automatically.
+			n := len(derefs)
+			if n > 0 && derefs[n-1].Elem().Kind() == reflect.Array {
+				n--
+			}
+			expr = derefExpr(expr, n)
+			derefs = derefs[:0]
+			if elem.kind == indexPath {
+				expr += "[" + strconv.Itoa(elem.index) + "]"
+			} else {
+				expr += "[" + d.sprint(elem.key) + "]"
+			}
+		case interfacePath:
+			if i < len(f.path)-1 {
+				expr = derefExpr(expr, len(derefs)) + ".(" + d.typeString(elem.typ) + ")"
+				derefs = derefs[:0]
+			}
+		}
+	}
+	return derefExpr(expr, len(derefs))
+}
+
+func derefExpr(expr string, n int) string {
+	for i := 0; i < n; i++ {
+		expr = "(*" + expr + ")"
+	}
+	return expr
+}
+
+// walkRefs walks v in the same order as dumping and calls visit for each node.
+// The children of the node are walked only if visit returns true.
+func (d *dumper) walkRefs(v reflect.Value, visit func(nodeKey, reflect.Value) bool) {
+	if !v.IsValid() {
+		return
+	}
+	// the value is written by the custom function.
+	if _, ok := d.customDumpFunc(v); ok {
+		return
+	}
+	if key, ok := nodeOf(v); ok {
+		if visit(key, v) {
+			d.walkChildren(v, visit)
+		}
+		return
+	}
+	d.walkChildren(v, visit)
+}
+
+func (d *dumper) walkChildren(v reflect.Value, visit func(nodeKey, reflect.Value) bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface {
+			d.graph.dynamicTypes[v.Elem().Type()] = true
+		}
+		d.walkRefs(v.Elem(), visit)
+	case reflect.Map:
+		if v.IsNil() {
+			return
+		}
+		// the entries are walked in the same order as writeMap
+		// to declare the shared nodes deterministically.
+		for _, key := range sort.Keys(v.MapKeys()) {
+			d.walkRefs(key, visit)
+			d.walkRefs(v.MapIndex(key), visit)
+		}
+	case reflect.Slice:
+		if v.IsNil() || v.Len() == 0 {
+			return
+		}
+		// slices are not nodes, but may be cyclic through interfaces.
+		pointer := v.Pointer()
+		if d.visitPointers[pointer] {
+			return
+		}
+		d.visitPointers[pointer] = true
+		defer delete(d.visitPointers, pointer)
+		for i := 0; i < v.Len(); i++ {
+			d.walkRefs(v.Index(i), visit)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			d.walkRefs(v.Index(i), visit)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if d.exportedOnly && !isExported(v.Type().Field(i)) {
+				continue
+			}
+			d.walkRefs(v.Field(i), visit)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+// Package diff provides the edit script between two sequences.
+package diff
+
+// Op is the kind of the edit.
+type Op int
+
+const (
+	// Equal means the elements are in both sequences.
+	Equal Op = iota
+	// Delete means the element is only in the first sequence.
+	Delete
+	// Insert means the element is only in the second sequence.
+	Insert
+)
+
+// Edit is an element of the edit script.
+// X is the index of the first sequence and Y is the index of the second sequence.
+// X is -1 for Insert and Y is -1 for Delete.
+type Edit struct {
+	Op   Op
+	X, Y int
+}
+
+// maxTableSize is the limit of the table to find the longest common subsequence.
+// The sequences which exceed it are compared by the index.
+const maxTableSize = 1 << 22
+
+// Strings returns the edit script to transform xs into ys.
+// The script keeps the longest common subsequence.
+func Strings(xs, ys []string) []Edit {
+	edits := make([]Edit, 0, len(xs)+len(ys))
+
+	// the common prefix and suffix are trimmed to reduce the table.
+	prefix := 0
+	for prefix < len(xs) && prefix < len(ys) && xs[prefix] == ys[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(xs)-prefix && suffix < len(ys)-prefix &&
+		xs[len(xs)-1-suffix] == ys[len(ys)-1-suffix] {
+		suffix++
+	}
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, Edit{Op: Equal, X: i, Y: i})
+	}
+	edits = append(edits, middle(xs[prefix:len(xs)-suffix], ys[prefix:len(ys)-suffix], prefix, prefix)...)
+	for i := suffix; i > 0; i-- {
+		edits = append(edits, Edit{Op: Equal, X: len(xs) - i, Y: len(ys) - i})
+	}
+	return edits
+}
+
+// middle returns the edit script of the trimmed sequences.
+// xOff and yOff are the offset of the indexes.
+func middle(xs, ys []string, xOff, yOff int) []Edit {
+	n, m := len(xs), len(ys)
+	var edits []Edit
+	if (n+1)*(m+1) > maxTableSize {
+		for i := 0; i < n; i++ {
+			edits = append(edits, Edit{Op: Delete, X: xOff + i, Y: -1})
+		}
+		for j := 0; j < m; j++ {
+			edits = append(edits, Edit{Op: Insert, X: -1, Y: yOff + j})
+		}
+		return edits
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of xs[i:] and ys[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if xs[i] == ys[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && xs[i] == ys[j]:
+			edits = append(edits, Edit{Op: Equal, X: xOff + i, Y: yOff + j})
+			i++
+			j++
+		case j == m || i < n && lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, Edit{Op: Delete, X: xOff + i, Y: -1})
+			i++
+		default:
+			edits = append(edits, Edit{Op: Insert, X: -1, Y: yOff + j})
+			j++
+		}
+	}
+	return edits
+}
@@ -0,0 +1,55 @@
+package diff_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Code-Hex/dd/internal/diff"
+)
+
+func TestStrings(t *testing.T) {
+	cases := []struct {
+		name string
+		xs   string
+		ys   string
+		want string
+	}{
+		{name: "empty", xs: "", ys: "", want: ""},
+		{name: "equal", xs: "abc", ys: "abc", want: " a b c"},
+		{name: "insert", xs: "ac", ys: "abc", want: " a+b c"},
+		{name: "delete", xs: "abc", ys: "ac", want: " a-b c"},
+		{name: "replace", xs: "abc", ys: "axc", want: " a-b+x c"},
+		{name: "shift", xs: "abcd", ys: "bcda", want: "-a b c d+a"},
+		{name: "all", xs: "ab", ys: "cd", want: "-a-b+c+d"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			xs, ys := split(tc.xs), split(tc.ys)
+			var got strings.Builder
+			for _, edit := range diff.Strings(xs, ys) {
+				switch edit.Op {
+				case diff.Equal:
+					if xs[edit.X] != ys[edit.Y] {
+						t.Fatalf("%q and %q are not equal", xs[edit.X], ys[edit.Y])
+					}
+					got.WriteString(" " + xs[edit.X])
+				case diff.Delete:
+					got.WriteString("-" + xs[edit.X])
+				case diff.Insert:
+					got.WriteString("+" + ys[edit.Y])
+				}
+			}
+			if tc.want != got.String() {
+				t.Fatalf("want %q, but got %q", tc.want, got.String())
+			}
+		})
+	}
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "")
+}
@@ -0,0 +1,8 @@
+// Package v1 is used by the tests for the packages which have the same name
+// as github.com/Code-Hex/dd/internal/testpkg/core/v1.
+package v1
+
+// Deployment is a struct type in the package.
+type Deployment struct {
+	Replicas int
+}
@@ -0,0 +1,14 @@
+// Package v1 is used by the tests for the packages whose name is
+// the major version like k8s.io/api/core/v1.
+package v1
+
+// Pod is a struct type in the package.
+type Pod struct {
+	Name string
+	Meta Meta
+}
+
+// Meta is a struct type in the package.
+type Meta struct {
+	Labels map[string]string
+}
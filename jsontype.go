@@ -0,0 +1,400 @@
+package dd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DumpJSONFile infers the struct types from the shape of the JSON data and
+// dumps the data as the Go source file which declares these types and
+// the variable named varName in package pkgName.
+//
+// The JSON objects are declared as the struct types and the root type is
+// named typeName. The other types are named after the keys of the objects.
+// The shapes of the array elements are merged, and the fields which are
+// missing or null in some elements are declared as the pointers.
+// The numbers are declared as int64 if all of them are integral, otherwise float64.
+//
+//	dd.DumpJSONFile("fixtures", "user", "User", []byte(`{"id": 1, "tags": [{"name": "a"}]}`))
+//	// package fixtures
+//	//
+//	// type User struct {
+//	// 	ID   int64 `json:"id"`
+//	// 	Tags []Tag `json:"tags"`
+//	// }
+//	//
+//	// type Tag struct {
+//	// 	Name string `json:"name"`
+//	// }
+//	//
+//	// var user = User{
+//	// 	...
+func DumpJSONFile(pkgName, varName, typeName string, data []byte, opts ...OptionFunc) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	s, err := inferShape(dec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("failed to unmarshal JSON: unexpected data after the JSON value")
+	}
+
+	b := &typeBuilder{
+		names: make(map[reflect.Type]string),
+		used:  map[string]bool{varName: true},
+	}
+	typ := b.build(s, typeName)
+	value := reflect.New(typ)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON into the inferred type: %w", err)
+	}
+
+	d := newDataDumper(nil, value.Elem().Interface(), opts...)
+	reserved := []string{varName}
+	for _, name := range b.names {
+		reserved = append(reserved, name)
+	}
+	d.initImports(reserved...)
+	d.typeNames = b.names
+	return d.dumpFile(pkgName, varName, d.typeDecls(typ))
+}
+
+type shapeKind int
+
+const (
+	nullShape shapeKind = iota
+	boolShape
+	intShape
+	floatShape
+	stringShape
+	arrayShape
+	objectShape
+	// mixedShape is the shape of the values which have the different kinds.
+	mixedShape
+)
+
+// shape is the shape of the JSON values merged each other.
+type shape struct {
+	kind shapeKind
+	// elem is the shape of the elements of the arrays. It is nil if all arrays are empty.
+	elem *shape
+	// fields are the fields of the objects in order of appearance.
+	fields []*shapeField
+	// count is the number of the merged objects.
+	count int
+}
+
+type shapeField struct {
+	key   string
+	shape *shape
+	// count is the number of the objects which have the non-null value of the field.
+	count int
+}
+
+// inferShape reads the JSON value from dec and returns its shape.
+// The tokens are read one by one to keep the order of the object keys.
+func inferShape(dec *json.Decoder) (*shape, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok := tok.(type) {
+	case bool:
+		return &shape{kind: boolShape}, nil
+	case json.Number:
+		if _, err := tok.Int64(); err == nil {
+			return &shape{kind: intShape}, nil
+		}
+		return &shape{kind: floatShape}, nil
+	case string:
+		return &shape{kind: stringShape}, nil
+	case json.Delim:
+		switch tok {
+		case '[':
+			s := &shape{kind: arrayShape}
+			for dec.More() {
+				elem, err := inferShape(dec)
+				if err != nil {
+					return nil, err
+				}
+				s.elem = mergeShape(s.elem, elem)
+			}
+			// ]
+			_, err := dec.Token()
+			return s, err
+		case '{':
+			s := &shape{kind: objectShape, count: 1}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := inferShape(dec)
+				if err != nil {
+					return nil, err
+				}
+				field := &shapeField{key: key.(string), shape: value}
+				if value.kind != nullShape {
+					field.count = 1
+				}
+				s.fields = append(s.fields, field)
+			}
+			// }
+			_, err := dec.Token()
+			return s, err
+		}
+	}
+	return &shape{kind: nullShape}, nil
+}
+
+// mergeShape merges the shapes a and b. a is modified and returned.
+func mergeShape(a, b *shape) *shape {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.kind == nullShape:
+		return b
+	case b.kind == nullShape:
+		return a
+	case a.kind == mixedShape || b.kind == mixedShape:
+		return &shape{kind: mixedShape}
+	case a.kind == intShape && b.kind == floatShape:
+		return b
+	case a.kind == floatShape && b.kind == intShape:
+		return a
+	case a.kind != b.kind:
+		return &shape{kind: mixedShape}
+	}
+	switch a.kind {
+	case arrayShape:
+		a.elem = mergeShape(a.elem, b.elem)
+	case objectShape:
+		for _, fb := range b.fields {
+			if fa := a.field(fb.key); fa != nil {
+				fa.shape = mergeShape(fa.shape, fb.shape)
+				fa.count += fb.count
+				continue
+			}
+			a.fields = append(a.fields, fb)
+		}
+		a.count += b.count
+	}
+	return a
+}
+
+func (s *shape) field(key string) *shapeField {
+	for _, f := range s.fields {
+		if f.key == key {
+			return f
+		}
+	}
+	return nil
+}
+
+// typeBuilder builds the types of the shapes. The struct types are created
+// as the unnamed types, and their names are recorded.
+type typeBuilder struct {
+	names map[reflect.Type]string
+	used  map[string]bool
+}
+
+func (b *typeBuilder) build(s *shape, name string) reflect.Type {
+	if s == nil {
+		return emptyInterfaceType
+	}
+	switch s.kind {
+	case boolShape:
+		return reflect.TypeOf(false)
+	case intShape:
+		return reflect.TypeOf(int64(0))
+	case floatShape:
+		return reflect.TypeOf(float64(0))
+	case stringShape:
+		return reflect.TypeOf("")
+	case arrayShape:
+		return reflect.SliceOf(b.build(s.elem, name))
+	case objectShape:
+		return b.buildStruct(s, name)
+	}
+	return emptyInterfaceType
+}
+
+func (b *typeBuilder) buildStruct(s *shape, name string) reflect.Type {
+	fields := make([]reflect.StructField, 0, len(s.fields))
+	usedFields := make(map[string]bool)
+	for _, f := range s.fields {
+		fieldName := uniqueName(usedFields, fieldNameOf(f.key))
+		usedFields[fieldName] = true
+
+		elemName := fieldName
+		if f.shape.kind == arrayShape {
+			elemName = singular(fieldName)
+		}
+		typ := b.build(f.shape, elemName)
+		tag := "json:" + strconv.Quote(f.key)
+		// the field is missing or null in some objects.
+		if f.count < s.count {
+			switch typ.Kind() {
+			case reflect.Slice, reflect.Map, reflect.Interface:
+			default:
+				typ = reflect.PtrTo(typ)
+			}
+			tag = "json:" + strconv.Quote(f.key+",omitempty")
+		}
+		fields = append(fields, reflect.StructField{
+			Name: fieldName,
+			Type: typ,
+			Tag:  reflect.StructTag(tag),
+		})
+	}
+	typ := reflect.StructOf(fields)
+	// the objects which have the same shape share the type.
+	if _, ok := b.names[typ]; !ok {
+		name = uniqueName(b.used, name)
+		b.used[name] = true
+		b.names[typ] = name
+	}
+	return typ
+}
+
+// uniqueName returns name which is not used by appending the number.
+func uniqueName(used map[string]bool, name string) string {
+	ret := name
+	for i := 2; used[ret]; i++ {
+		ret = name + strconv.Itoa(i)
+	}
+	return ret
+}
+
+// commonInitialisms is the words which are written in upper case in Go.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true, "XMPP": true,
+	"XSRF": true, "XSS": true,
+}
+
+// fieldNameOf converts the key of JSON object to the exported identifier.
+//
+//	user_id    => UserID
+//	screenName => ScreenName
+func fieldNameOf(key string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(key)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		// e.g. screenName, HTMLParser
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var buf strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			buf.WriteString(upper)
+			continue
+		}
+		r := []rune(word)
+		buf.WriteRune(unicode.ToUpper(r[0]))
+		buf.WriteString(string(r[1:]))
+	}
+	name := buf.String()
+	if name == "" {
+		return "Field"
+	}
+	if r := []rune(name)[0]; !unicode.IsLetter(r) || !unicode.IsUpper(r) {
+		// e.g. 1st, _id
+		name = "X" + name
+	}
+	return name
+}
+
+// singular returns the singular form of the name roughly.
+//
+//	Items    => Item
+//	Entries  => Entry
+//	Statuses => Status
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "uses"),
+		strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ss"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// typeDecls returns the declarations of the named struct types reachable
+// from typ in depth-first order.
+func (d *dumper) typeDecls(typ reflect.Type) []string {
+	var decls []string
+	visited := make(map[reflect.Type]bool)
+	var walk func(typ reflect.Type)
+	walk = func(typ reflect.Type) {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			walk(typ.Elem())
+			return
+		case reflect.Struct:
+		default:
+			return
+		}
+		name, ok := d.typeNames[typ]
+		if !ok || visited[typ] {
+			return
+		}
+		visited[typ] = true
+
+		var buf strings.Builder
+		buf.WriteString("type " + name + " struct {\n")
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			tag := string(field.Tag)
+			if strings.Contains(tag, "`") {
+				tag = strconv.Quote(tag)
+			} else {
+				tag = "`" + tag + "`"
+			}
+			fmt.Fprintf(&buf, "%s %s %s\n", field.Name, d.typeString(field.Type), tag)
+		}
+		buf.WriteString("}")
+		decls = append(decls, buf.String())
+
+		for i := 0; i < typ.NumField(); i++ {
+			walk(typ.Field(i).Type)
+		}
+	}
+	walk(typ)
+	return decls
+}
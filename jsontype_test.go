@@ -0,0 +1,157 @@
+package dd_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Code-Hex/dd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDumpJSONFile(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{
+			name: "object",
+			data: `{"id": 1, "screen_name": "codehex", "profile_url": "https://example.com", "score": 1.5}`,
+			want: `package fixtures
+
+type User struct {
+	ID         int64   ` + "`" + `json:"id"` + "`" + `
+	ScreenName string  ` + "`" + `json:"screen_name"` + "`" + `
+	ProfileURL string  ` + "`" + `json:"profile_url"` + "`" + `
+	Score      float64 ` + "`" + `json:"score"` + "`" + `
+}
+
+var data = User{
+	ID:         1,
+	ScreenName: "codehex",
+	ProfileURL: "https://example.com",
+	Score:      1.5,
+}
+`,
+		},
+		{
+			name: "merged elements",
+			data: `{"entries": [{"n": 1, "tags": []}, {"n": 1.5, "tags": ["a"], "ok": true}, {"n": 2, "ok": null}]}`,
+			want: `package fixtures
+
+type User struct {
+	Entries []Entry ` + "`" + `json:"entries"` + "`" + `
+}
+
+type Entry struct {
+	N    float64  ` + "`" + `json:"n"` + "`" + `
+	Tags []string ` + "`" + `json:"tags,omitempty"` + "`" + `
+	Ok   *bool    ` + "`" + `json:"ok,omitempty"` + "`" + `
+}
+
+var data = User{
+	Entries: []Entry{
+		Entry{
+			N:    1.0,
+			Tags: []string{},
+			Ok:   (*bool)(nil),
+		},
+		Entry{
+			N: 1.5,
+			Tags: []string{
+				"a",
+			},
+			Ok: func() *bool { v := true; return &v }(),
+		},
+		Entry{
+			N:    2.0,
+			Tags: ([]string)(nil),
+			Ok:   (*bool)(nil),
+		},
+	},
+}
+`,
+		},
+		{
+			name: "same shapes",
+			data: `[{"from": {"id": 1}, "to": {"id": 2}, "mixed": [1, "a"]}]`,
+			want: `package fixtures
+
+type User struct {
+	From  From          ` + "`" + `json:"from"` + "`" + `
+	To    From          ` + "`" + `json:"to"` + "`" + `
+	Mixed []interface{} ` + "`" + `json:"mixed"` + "`" + `
+}
+
+type From struct {
+	ID int64 ` + "`" + `json:"id"` + "`" + `
+}
+
+var data = []User{
+	User{
+		From: From{
+			ID: 1,
+		},
+		To: From{
+			ID: 2,
+		},
+		Mixed: []interface{}{
+			1.0,
+			"a",
+		},
+	},
+}
+`,
+		},
+		{
+			name: "primitive",
+			data: `"text"`,
+			want: "package fixtures\n\nvar data = \"text\"\n",
+		},
+		{
+			name: "null",
+			data: `null`,
+			want: "package fixtures\n\nvar data = interface{}(nil)\n",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := dd.DumpJSONFile("fixtures", "data", "User", []byte(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDumpJSONFileLarge(t *testing.T) {
+	data, err := os.ReadFile("testdata/twitter-search-adaptive/data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := dd.DumpJSONFile("fixtures", "data", "Response", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "type Response struct {") {
+		t.Errorf("want the root type is declared, but got:\n%s", src)
+	}
+}
+
+func TestDumpJSONFileError(t *testing.T) {
+	for _, data := range []string{`{"a": }`, `{} {}`} {
+		if _, err := dd.DumpJSONFile("fixtures", "data", "User", []byte(data)); err == nil {
+			t.Errorf("want error for %q", data)
+		}
+	}
+}
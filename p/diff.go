@@ -0,0 +1,121 @@
+package p
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Code-Hex/dd"
+	"github.com/alecthomas/chroma"
+	"github.com/mattn/go-colorable"
+)
+
+// WithChangesOnly is an option to print only the changed lines of the diff
+// and the specified number of unchanged lines around them.
+// By default, all lines of the diff are printed.
+func WithChangesOnly(contextLines int) OptionFunc {
+	return func(opts *options) {
+		if contextLines < 0 {
+			contextLines = 0
+		}
+		opts.contextLines = contextLines
+	}
+}
+
+// Diff prints the difference between a and b which are dumped with colored.
+// The removed lines and the added lines are highlighted as deleted and
+// inserted text of the style. Nothing is printed if there is no difference.
+// It returns the number of bytes written and any write error encountered.
+func (p *Printer) Diff(a, b interface{}) (int, error) {
+	return p.Fdiff(colorable.NewColorableStdout(), a, b)
+}
+
+// Fdiff prints the difference between a and b which are dumped with colored and writes to w.
+// The removed lines and the added lines are highlighted as deleted and
+// inserted text of the style. Nothing is printed if there is no difference.
+// It returns the number of bytes written and any write error encountered.
+func (p *Printer) Fdiff(w io.Writer, a, b interface{}) (int, error) {
+	diff := dd.Diff(a, b, p.options.ddOptions...)
+	if diff == "" {
+		return 0, nil
+	}
+	lines := strings.Split(diff, "\n")
+	if p.options.contextLines >= 0 {
+		lines = changesOnly(lines, p.options.contextLines)
+	}
+
+	var tokens []chroma.Token
+	for _, line := range lines {
+		line += "\n"
+		switch line[0] {
+		case '-':
+			tokens = append(tokens, chroma.Token{Type: chroma.GenericDeleted, Value: line})
+		case '+':
+			tokens = append(tokens, chroma.Token{Type: chroma.GenericInserted, Value: line})
+		default:
+			iterator, _ := lexer.Tokenise(nil, line)
+			tokens = append(tokens, iterator.Tokens()...)
+		}
+	}
+	var buf bytes.Buffer
+	p.options.formatter.Format(&buf, p.options.style, chroma.Literator(tokens...))
+	cpn, cperr := io.Copy(w, &buf)
+	return int(cpn), cperr
+}
+
+// changesOnly returns the changed lines and the unchanged lines within n lines of them.
+// The omitted lines are replaced with a line which has the number of them.
+func changesOnly(lines []string, n int) []string {
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		if line[0] == ' ' {
+			continue
+		}
+		for j := i - n; j <= i+n; j++ {
+			if 0 <= j && j < len(lines) {
+				keep[j] = true
+			}
+		}
+	}
+	var ret []string
+	omitted := 0
+	for i, line := range lines {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		if omitted > 0 {
+			ret = append(ret, omittedLine(omitted))
+			omitted = 0
+		}
+		ret = append(ret, line)
+	}
+	if omitted > 0 {
+		ret = append(ret, omittedLine(omitted))
+	}
+	return ret
+}
+
+func omittedLine(n int) string {
+	if n == 1 {
+		return "  ... // 1 unchanged line"
+	}
+	return "  ... // " + strconv.Itoa(n) + " unchanged lines"
+}
+
+// Diff prints the difference between a and b which are dumped with colored.
+// The removed lines and the added lines are highlighted as deleted and
+// inserted text of the style. Nothing is printed if there is no difference.
+// It returns the number of bytes written and any write error encountered.
+func Diff(a, b interface{}) (int, error) {
+	return defaultPrinter.Diff(a, b)
+}
+
+// Fdiff prints the difference between a and b which are dumped with colored and writes to w.
+// The removed lines and the added lines are highlighted as deleted and
+// inserted text of the style. Nothing is printed if there is no difference.
+// It returns the number of bytes written and any write error encountered.
+func Fdiff(w io.Writer, a, b interface{}) (int, error) {
+	return defaultPrinter.Fdiff(w, a, b)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Code-Hex/dd/p"
+	"github.com/alecthomas/chroma/formatters"
 	"github.com/alecthomas/chroma/styles"
 )
 
@@ -39,3 +40,45 @@ func ExamplePrinter_Fp() {
 	// Output:
 	// [38;5;228m"Hello, World"[0m
 }
+
+func ExampleFdiff() {
+	// prints the difference with colored.
+	var buf bytes.Buffer
+	p.Fdiff(&buf, []int{1, 2}, []int{1, 3})
+	fmt.Print(buf.String())
+	// Output:
+	// [38;5;231m  [0m[38;5;231m[[0m[38;5;231m][0m[38;5;81mint[0m[38;5;231m{[0m[38;5;231m
+	// [0m[38;5;231m    [0m[38;5;197m...[0m[38;5;231m [0m[38;5;242m// 1 identical element
+	// [0m[38;5;197m-   2,
+	// [0m[38;5;148m+   3,
+	// [0m[38;5;231m  [0m[38;5;231m}[0m[38;5;231m
+	// [0m
+}
+
+func ExamplePrinter_Fdiff() {
+	type config struct {
+		Name    string
+		Port    int
+		Debug   bool
+		Timeout int
+		Retry   int
+	}
+	// prints only the changes without colors.
+	printer := p.New(
+		p.WithFormatter(formatters.NoOp),
+		p.WithChangesOnly(1),
+	)
+	var buf bytes.Buffer
+	printer.Fdiff(&buf,
+		[]config{{Name: "a", Port: 80}, {Name: "b"}},
+		[]config{{Name: "a", Port: 8080}, {Name: "b"}},
+	)
+	fmt.Print(buf.String())
+	// Output:
+	//   ... // 2 unchanged lines
+	//       ... // 1 identical field
+	// -     Port: 80,
+	// +     Port: 8080,
+	//       ... // 3 identical fields
+	//   ... // 3 unchanged lines
+}
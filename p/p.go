@@ -21,12 +21,16 @@ type options struct {
 	ddOptions []dd.OptionFunc
 	style     *chroma.Style
 	formatter chroma.Formatter
+	// contextLines is the number of unchanged lines around the changes in the diff.
+	// All lines are printed if it is negative.
+	contextLines int
 }
 
 func newOptions() *options {
 	return &options{
-		style:     styles.Monokai,
-		formatter: formatters.TTY256, // Format method returns error is nil
+		style:        styles.Monokai,
+		formatter:    formatters.TTY256, // Format method returns error is nil
+		contextLines: -1,
 	}
 }
 
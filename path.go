@@ -0,0 +1,43 @@
+package dd
+
+import "reflect"
+
+// pathKind is a kind of the element of the path to the value being dumped.
+type pathKind int
+
+const (
+	// fieldPath is a field of the struct. e.g. .Name
+	fieldPath pathKind = iota
+	// indexPath is an element of the slice or array. e.g. [0]
+	indexPath
+	// mapKeyPath is a value of the map. e.g. ["key"]
+	mapKeyPath
+	// derefPath is a dereference of the pointer.
+	derefPath
+	// interfacePath is a value held in the interface.
+	interfacePath
+)
+
+// pathElem is an element of the path to the value being dumped.
+type pathElem struct {
+	kind  pathKind
+	name  string        // field name for fieldPath
+	index int           // index for indexPath
+	key   reflect.Value // key for mapKeyPath
+	typ   reflect.Type  // pointer type for derefPath, list type for indexPath and dynamic type for interfacePath
+}
+
+func (d *dumper) pushPath(elem pathElem) {
+	d.path = append(d.path, elem)
+}
+
+func (d *dumper) popPath() {
+	d.path = d.path[:len(d.path)-1]
+}
+
+// dumpElem dumps obj as the element of the value being dumped.
+func (d *dumper) dumpElem(elem pathElem, obj interface{}) {
+	d.pushPath(elem)
+	d.dump(obj)
+	d.popPath()
+}
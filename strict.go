@@ -0,0 +1,82 @@
+package dd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnrepresentableError is returned by DumpStrict if the dumped data has
+// the values which can not be represented as Go source.
+type UnrepresentableError struct {
+	Values []UnrepresentableValue
+}
+
+// UnrepresentableValue is the value which can not be represented as Go source.
+type UnrepresentableValue struct {
+	// Path is the path to the value from the root. e.g. .Config.conn.mu
+	// The root value is represented as the empty string.
+	Path string
+	// Reason describes why the value can not be represented.
+	Reason string
+}
+
+func (e *UnrepresentableError) Error() string {
+	var buf strings.Builder
+	buf.WriteString("failed to represent the data as Go source:")
+	for _, v := range e.Values {
+		path := v.Path
+		if path == "" {
+			path = "(root)"
+		}
+		fmt.Fprintf(&buf, "\n\t%s: %s", path, v.Reason)
+	}
+	return buf.String()
+}
+
+// report records the value being dumped as unrepresentable in strict mode.
+// The values under the reported value are not reported again.
+func (d *dumper) report(format string, a ...interface{}) {
+	if !d.strict {
+		return
+	}
+	path := d.pathString()
+	for _, v := range d.unrepresentable {
+		if isSubPath(path, v.Path) {
+			return
+		}
+	}
+	d.unrepresentable = append(d.unrepresentable, UnrepresentableValue{
+		Path:   path,
+		Reason: fmt.Sprintf(format, a...),
+	})
+}
+
+// isSubPath reports whether path is the same as parent or under the parent.
+func isSubPath(path, parent string) bool {
+	if !strings.HasPrefix(path, parent) {
+		return false
+	}
+	return len(path) == len(parent) || path[len(parent)] == '.' || path[len(parent)] == '['
+}
+
+// pathString returns the path to the value being dumped in Go syntax.
+// The dereferences of pointers and the type assertions are omitted.
+func (d *dumper) pathString() string {
+	var buf strings.Builder
+	for _, elem := range d.path {
+		switch elem.kind {
+		case fieldPath:
+			buf.WriteString("." + elem.name)
+		case indexPath:
+			buf.WriteString("[" + strconv.Itoa(elem.index) + "]")
+		case mapKeyPath:
+			// the key itself is not checked.
+			strict := d.strict
+			d.strict = false
+			buf.WriteString("[" + d.sprint(elem.key) + "]")
+			d.strict = strict
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,94 @@
+package dd_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/Code-Hex/dd"
+	"github.com/google/go-cmp/cmp"
+)
+
+type strictConn struct {
+	mu   sync.Mutex
+	Name string
+}
+
+type strictConfig struct {
+	Conn     *strictConn
+	Handlers map[string]func()
+	Events   chan int
+	Any      interface{}
+	Ptr      unsafe.Pointer
+}
+
+func TestDumpStrict(t *testing.T) {
+	t.Run("representable", func(t *testing.T) {
+		v := map[string][]int{"a": {1, 2}}
+		got, err := dd.DumpStrict(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := dd.Dump(v); want != got {
+			t.Fatalf("want %q, but got %q", want, got)
+		}
+	})
+
+	t.Run("unrepresentable", func(t *testing.T) {
+		type node struct {
+			Next *node
+		}
+		n := &node{}
+		n.Next = n
+		x := 1
+		v := strictConfig{
+			Conn:     &strictConn{Name: "db"},
+			Handlers: map[string]func(){"close": func() {}},
+			Events:   make(chan int),
+			Any:      []interface{}{n, struct{ ok bool }{}},
+			Ptr:      unsafe.Pointer(&x),
+		}
+		got, err := dd.DumpStrict(v, dd.WithPackagePath("github.com/Code-Hex/dd_test"))
+		if want := dd.Dump(v, dd.WithPackagePath("github.com/Code-Hex/dd_test")); want != got {
+			t.Fatalf("want %q, but got %q", want, got)
+		}
+		var uerr *dd.UnrepresentableError
+		if !errors.As(err, &uerr) {
+			t.Fatalf("want *dd.UnrepresentableError, but got %T", err)
+		}
+		want := []dd.UnrepresentableValue{
+			{Path: ".Conn.mu", Reason: "unexported fields of sync.Mutex"},
+			{Path: `.Handlers["close"]`, Reason: "function body"},
+			{Path: ".Events", Reason: "channel"},
+			{Path: ".Any[0].Next", Reason: "circular reference"},
+			{Path: ".Ptr", Reason: "unsafe pointer"},
+		}
+		if diff := cmp.Diff(want, uerr.Values); diff != "" {
+			t.Fatalf("(-want, +got)\n%s", diff)
+		}
+	})
+
+	t.Run("other package", func(t *testing.T) {
+		type unexported struct {
+			name string
+		}
+		v := []interface{}{unexported{name: "a"}, (*strictConn)(nil)}
+		_, err := dd.DumpStrict(v, dd.WithPackagePath("example.com/other"))
+		var uerr *dd.UnrepresentableError
+		if !errors.As(err, &uerr) {
+			t.Fatalf("want *dd.UnrepresentableError, but got %T", err)
+		}
+		want := []dd.UnrepresentableValue{
+			{Path: "[0]", Reason: "unexported type dd_test.unexported"},
+			{Path: "[1]", Reason: "unexported type dd_test.strictConn"},
+		}
+		if diff := cmp.Diff(want, uerr.Values); diff != "" {
+			t.Fatalf("(-want, +got)\n%s", diff)
+		}
+		wantMsg := "failed to represent the data as Go source:\n\t[0]: unexported type dd_test.unexported\n\t[1]: unexported type dd_test.strictConn"
+		if got := err.Error(); wantMsg != got {
+			t.Fatalf("want %q, but got %q", wantMsg, got)
+		}
+	})
+}
@@ -0,0 +1,377 @@
+package dd
+
+import (
+	"go/token"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// typeString returns typ in Go syntax. The format is the same as reflect.Type.String,
+// but the package names are qualified by d.qualify.
+func (d *dumper) typeString(typ reflect.Type) string {
+	var buf strings.Builder
+	d.writeType(&buf, typ)
+	return buf.String()
+}
+
+func (d *dumper) writeType(buf *strings.Builder, typ reflect.Type) {
+	if name, ok := d.typeNames[typ]; ok {
+		buf.WriteString(name)
+		return
+	}
+	if typ.Name() != "" {
+		d.writeTypeName(buf, typ)
+		return
+	}
+	switch typ.Kind() {
+	case reflect.Ptr:
+		buf.WriteString("*")
+		d.writeType(buf, typ.Elem())
+	case reflect.Slice:
+		buf.WriteString("[]")
+		d.writeType(buf, typ.Elem())
+	case reflect.Array:
+		buf.WriteString("[" + strconv.Itoa(typ.Len()) + "]")
+		d.writeType(buf, typ.Elem())
+	case reflect.Map:
+		buf.WriteString("map[")
+		d.writeType(buf, typ.Key())
+		buf.WriteString("]")
+		d.writeType(buf, typ.Elem())
+	case reflect.Chan:
+		d.writeChanType(buf, typ)
+	case reflect.Func:
+		buf.WriteString("func")
+		d.writeSignature(buf, typ)
+	case reflect.Struct:
+		d.writeStructType(buf, typ)
+	case reflect.Interface:
+		d.writeInterfaceType(buf, typ)
+	default:
+		buf.WriteString(typ.String())
+	}
+}
+
+// writeTypeName writes the defined type (or predeclared type) with the package name.
+func (d *dumper) writeTypeName(buf *strings.Builder, typ reflect.Type) {
+	pkgPath := typ.PkgPath()
+	if pkgPath == "" {
+		// predeclared types. e.g. int, error
+		buf.WriteString(typ.Name())
+		return
+	}
+	// the package name is not provided by reflect.Type,
+	// but it is written as the prefix of the string.
+	s := typ.String()
+	pkgName := s[:strings.IndexByte(s, '.')]
+	d.addPackageName(pkgPath, pkgName)
+	if pkgPath != d.packagePath && !token.IsExported(typ.Name()) {
+		d.report("unexported type %s", s)
+	}
+	if qualifier := d.qualify(pkgPath, pkgName); qualifier != "" {
+		buf.WriteString(qualifier + ".")
+	}
+	name := typ.Name()
+	// the name of the instantiated generic type has the type arguments.
+	// e.g. Pair[int,example.com/pkg.ID]
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		buf.WriteString(name[:i])
+		d.addPackageNamesOf(typ)
+		d.writeTypeArgs(buf, name[i:])
+		return
+	}
+	buf.WriteString(name)
+}
+
+// writeTypeArgs writes the type arguments of the instantiated generic type.
+// The defined types in the arguments are written with the full package path
+// by reflect, so they are rewritten to be qualified by d.qualify.
+//
+//	[int,example.com/pkg.ID] => [int, pkg.ID]
+func (d *dumper) writeTypeArgs(buf *strings.Builder, args string) {
+	for i := 0; i < len(args); {
+		c := args[i]
+		switch {
+		case c == '"' || c == '`':
+			// struct tags
+			j := i + 1
+			for j < len(args) && args[j] != c {
+				if c == '"' && args[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j < len(args) {
+				j++
+			}
+			buf.WriteString(args[i:j])
+			i = j
+		case c == ',':
+			// the arguments are separated without spaces.
+			buf.WriteString(",")
+			if i+1 < len(args) && args[i+1] != ' ' {
+				buf.WriteString(" ")
+			}
+			i++
+		case strings.HasPrefix(args[i:], "..."):
+			buf.WriteString("...")
+			i += 3
+		case isPathChar(c):
+			j := i
+			for j < len(args) && isPathChar(args[j]) {
+				j++
+			}
+			d.writeQualifiedName(buf, args[i:j])
+			i = j
+		default:
+			buf.WriteByte(c)
+			i++
+		}
+	}
+}
+
+// writeQualifiedName writes the identifier which may be qualified by the package path.
+// e.g. example.com/pkg.ID
+func (d *dumper) writeQualifiedName(buf *strings.Builder, ident string) {
+	dot := strings.LastIndexByte(ident, '.')
+	if dot < 0 {
+		buf.WriteString(ident)
+		return
+	}
+	pkgPath := ident[:dot]
+	pkgName, ok := d.pkgNames[pkgPath]
+	if !ok {
+		pkgName = packageNameOf(pkgPath)
+	}
+	if qualifier := d.qualify(pkgPath, pkgName); qualifier != "" {
+		buf.WriteString(qualifier + ".")
+	}
+	buf.WriteString(ident[dot+1:])
+}
+
+// addPackageName records the name of the package.
+func (d *dumper) addPackageName(pkgPath, pkgName string) {
+	if d.pkgNames == nil {
+		d.pkgNames = make(map[string]string)
+	}
+	d.pkgNames[pkgPath] = pkgName
+}
+
+// addPackageNamesOf records the names of the packages of the defined types
+// reachable from the instantiated generic type, because the type arguments
+// are written with only the package path by reflect.
+// e.g. the fields of Pair[int,k8s.io/api/core/v1.Pod] have v1.Pod
+func (d *dumper) addPackageNamesOf(typ reflect.Type) {
+	walkTypes(typ, make(map[reflect.Type]bool), func(typ reflect.Type) {
+		if pkgPath := typ.PkgPath(); pkgPath != "" {
+			s := typ.String()
+			d.addPackageName(pkgPath, s[:strings.IndexByte(s, '.')])
+		}
+	})
+}
+
+// walkTypes calls f for typ and the types of its elements, fields, parameters
+// and results recursively. The types in seen are skipped.
+func walkTypes(typ reflect.Type, seen map[reflect.Type]bool, f func(reflect.Type)) {
+	if seen[typ] {
+		return
+	}
+	seen[typ] = true
+	f(typ)
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Chan:
+		walkTypes(typ.Elem(), seen, f)
+	case reflect.Map:
+		walkTypes(typ.Key(), seen, f)
+		walkTypes(typ.Elem(), seen, f)
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			walkTypes(typ.Field(i).Type, seen, f)
+		}
+	case reflect.Func:
+		for i := 0; i < typ.NumIn(); i++ {
+			walkTypes(typ.In(i), seen, f)
+		}
+		for i := 0; i < typ.NumOut(); i++ {
+			walkTypes(typ.Out(i), seen, f)
+		}
+	}
+}
+
+func isPathChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '_' || c == '.' || c == '/' || c == '-' || c == '~'
+}
+
+// packageNameOf guesses the package name from the import path
+// because reflect does not provide it for the type arguments.
+//
+//	example.com/pkg/v2 => pkg
+//	gopkg.in/yaml.v3   => yaml
+//	example.com/go-pkg => pkg
+func packageNameOf(pkgPath string) string {
+	elems := strings.Split(pkgPath, "/")
+	name := elems[len(elems)-1]
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+	if i := strings.LastIndex(name, ".v"); i > 0 && isMajorVersion(name[i+1:]) {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	var ret strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' && ret.Len() > 0 {
+			ret.WriteByte(c)
+		}
+	}
+	if ret.Len() == 0 {
+		return "pkg"
+	}
+	return ret.String()
+}
+
+// isMajorVersion reports whether s is like "v2".
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *dumper) writeChanType(buf *strings.Builder, typ reflect.Type) {
+	elem := typ.Elem()
+	switch typ.ChanDir() {
+	case reflect.RecvDir:
+		buf.WriteString("<-chan ")
+	case reflect.SendDir:
+		buf.WriteString("chan<- ")
+	default:
+		// "chan <-chan int" is parsed as "chan<- chan int"
+		if elem.Name() == "" && elem.Kind() == reflect.Chan && elem.ChanDir() == reflect.RecvDir {
+			buf.WriteString("chan (")
+			d.writeType(buf, elem)
+			buf.WriteString(")")
+			return
+		}
+		buf.WriteString("chan ")
+	}
+	d.writeType(buf, elem)
+}
+
+// writeSignature writes parameters and results of the function type.
+func (d *dumper) writeSignature(buf *strings.Builder, typ reflect.Type) {
+	buf.WriteString("(")
+	for i := 0; i < typ.NumIn(); i++ {
+		if i > 0 {
+			buf.WriteString(", ")
+		}
+		in := typ.In(i)
+		if typ.IsVariadic() && i == typ.NumIn()-1 {
+			buf.WriteString("...")
+			in = in.Elem()
+		}
+		d.writeType(buf, in)
+	}
+	buf.WriteString(")")
+	switch numOut := typ.NumOut(); numOut {
+	case 0:
+	case 1:
+		buf.WriteString(" ")
+		d.writeType(buf, typ.Out(0))
+	default:
+		buf.WriteString(" (")
+		for i := 0; i < numOut; i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			d.writeType(buf, typ.Out(i))
+		}
+		buf.WriteString(")")
+	}
+}
+
+func (d *dumper) writeStructType(buf *strings.Builder, typ reflect.Type) {
+	if typ.NumField() == 0 {
+		buf.WriteString("struct {}")
+		return
+	}
+	buf.WriteString("struct { ")
+	for i := 0; i < typ.NumField(); i++ {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		field := typ.Field(i)
+		if !field.Anonymous {
+			buf.WriteString(field.Name + " ")
+		}
+		d.writeType(buf, field.Type)
+		if field.Tag != "" {
+			buf.WriteString(" " + strconv.Quote(string(field.Tag)))
+		}
+	}
+	buf.WriteString(" }")
+}
+
+func (d *dumper) writeInterfaceType(buf *strings.Builder, typ reflect.Type) {
+	if typ.NumMethod() == 0 {
+		buf.WriteString("interface {}")
+		return
+	}
+	for i := 0; i < typ.NumMethod(); i++ {
+		// unexported methods can not be written in Go syntax.
+		if typ.Method(i).PkgPath != "" {
+			buf.WriteString(typ.String())
+			return
+		}
+	}
+	buf.WriteString("interface { ")
+	for i := 0; i < typ.NumMethod(); i++ {
+		if i > 0 {
+			buf.WriteString("; ")
+		}
+		method := typ.Method(i)
+		buf.WriteString(method.Name)
+		d.writeSignature(buf, method.Type)
+	}
+	buf.WriteString(" }")
+}
+
+// qualify returns the name to qualify the identifiers of the package.
+// It returns empty if the identifiers need not be qualified.
+func (d *dumper) qualify(pkgPath, pkgName string) string {
+	if pkgPath == d.packagePath {
+		return ""
+	}
+	if d.imports != nil {
+		return d.imports.qualify(pkgPath, pkgName)
+	}
+	return pkgName
+}
+
+// addImportCandidate records the package of typ which may be referred by
+// the custom dump function for typ.
+func (d *dumper) addImportCandidate(typ reflect.Type) {
+	if d.imports == nil {
+		return
+	}
+	for typ.Name() == "" {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+			typ = typ.Elem()
+		default:
+			return
+		}
+	}
+	if pkgPath := typ.PkgPath(); pkgPath != "" && pkgPath != d.packagePath {
+		s := typ.String()
+		d.imports.addCandidate(pkgPath, s[:strings.IndexByte(s, '.')])
+	}
+}
@@ -0,0 +1,105 @@
+//go:build go1.18
+// +build go1.18
+
+package dd_test
+
+import (
+	"go/parser"
+	"net/textproto"
+	"testing"
+	"time"
+
+	"github.com/Code-Hex/dd"
+	v1 "github.com/Code-Hex/dd/internal/testpkg/core/v1"
+	"github.com/google/go-cmp/cmp"
+)
+
+type pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+type box[T any] struct {
+	V T
+}
+
+// taggedFunc is the unnamed type which has the struct tag.
+type taggedFunc = func(int, ...string) (struct {
+	A int `json:"a,b"`
+}, error)
+
+func TestGenericType(t *testing.T) {
+	cases := []struct {
+		name string
+		v    interface{}
+		opts []dd.OptionFunc
+		want string
+	}{
+		{
+			name: "qualified type arguments",
+			v:    pair[time.Duration, textproto.MIMEHeader]{Key: time.Second},
+			want: "dd_test.pair[time.Duration, textproto.MIMEHeader]{\n  Key: 1000000000,\n  Value: (textproto.MIMEHeader)(nil),\n}",
+		},
+		{
+			name: "nested type arguments",
+			v:    (*box[map[string][]*pair[int, time.Month]])(nil),
+			want: "(*dd_test.box[map[string][]*dd_test.pair[int, time.Month]])(nil)",
+		},
+		{
+			name: "struct and func type arguments",
+			v:    box[taggedFunc]{},
+			want: "dd_test.box[func(int, ...string) (struct { A int \"json:\\\"a,b\\\"\" }, error)]{\n  V: (func(int, ...string) (struct { A int \"json:\\\"a,b\\\"\" }, error))(nil),\n}",
+		},
+		{
+			name: "package named major version",
+			v:    pair[int, v1.Meta]{Key: 1},
+			want: "dd_test.pair[int, v1.Meta]{\n  Key: 1,\n  Value: v1.Meta{\n    Labels: (map[string]string)(nil),\n  },\n}",
+		},
+		{
+			name: "nested package named major version",
+			v:    box[*pair[string, []v1.Pod]]{},
+			want: "dd_test.box[*dd_test.pair[string, []v1.Pod]]{\n  V: (*dd_test.pair[string, []v1.Pod])(nil),\n}",
+		},
+		{
+			name: "current package",
+			v:    map[string]box[pair[int, time.Month]]{},
+			opts: []dd.OptionFunc{dd.WithPackagePath("github.com/Code-Hex/dd_test")},
+			want: "map[string]box[pair[int, time.Month]]{}",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got := dd.Dump(tc.v, tc.opts...)
+			if tc.want != got {
+				t.Fatalf("want %q, but got %q", tc.want, got)
+			}
+			if _, err := parser.ParseExpr(got); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestDumpFileGenericType(t *testing.T) {
+	v := pair[time.Month, *textproto.Reader]{Key: time.May}
+	got, err := dd.DumpFile("example", "data", v, dd.WithPackagePath("github.com/Code-Hex/dd_test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package example
+
+import (
+	"net/textproto"
+	"time"
+)
+
+var data = pair[time.Month, *textproto.Reader]{
+	Key:   5,
+	Value: (*textproto.Reader)(nil),
+}
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Fatalf("(-want, +got)\n%s", diff)
+	}
+}
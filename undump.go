@@ -0,0 +1,948 @@
+package dd
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"math"
+	"reflect"
+	"strings"
+	"unicode"
+	"unsafe"
+)
+
+// Undump parses src which is dumped by dd and stores the value in target.
+// target must be a non-nil pointer.
+//
+// The named types are resolved with the types of target and registry.
+// The types which are referred from them (e.g. the types of the struct fields)
+// are also resolved. The other types must be registered as the values in registry.
+//
+//	var items []orders.Item
+//	err := dd.Undump(src, &items, orders.Discount(0), (*orders.Coupon)(nil))
+//
+// The supported syntax is the subset of Go which is written by dd. e.g. composite
+// literals, conversions, basic literals, math.NaN(), pointer helpers and the
+// immediately invoked functions written in graph-aware mode.
+func Undump(src string, target interface{}, registry ...interface{}) error {
+	rv := reflect.ValueOf(target)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("target must be a non-nil pointer")
+	}
+	fset := token.NewFileSet()
+	expr, err := parser.ParseExprFrom(fset, "", src, 0)
+	if err != nil {
+		return fmt.Errorf("failed to parse: %w", err)
+	}
+	u := &undumper{
+		src:   src,
+		fset:  fset,
+		types: make(map[string]reflect.Type),
+	}
+	u.registerType(rv.Type().Elem())
+	for _, v := range registry {
+		if typ := reflect.TypeOf(v); typ != nil {
+			u.registerType(typ)
+		}
+	}
+	v, err := u.eval(expr, rv.Type().Elem())
+	if err != nil {
+		return err
+	}
+	rv.Elem().Set(v)
+	return nil
+}
+
+// maxLiteralIndex is the limit of the index keys in the slice literals and
+// the array lengths not to allocate the huge value by the small source.
+// dd does not write the index keys, so it is enough for the hand-written
+// sources.
+const maxLiteralIndex = 1 << 20
+
+type undumper struct {
+	src  string
+	fset *token.FileSet
+	// types is the registered types keyed by the type expression
+	// which spaces are removed.
+	types map[string]reflect.Type
+	// scopes is the variables in the immediately invoked functions.
+	scopes []map[string]reflect.Value
+}
+
+// undumpError is the error with the position in the source.
+type undumpError struct {
+	pos token.Position
+	msg string
+}
+
+func (e *undumpError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.pos.Line, e.pos.Column, e.msg)
+}
+
+func (u *undumper) errorf(node ast.Node, format string, a ...interface{}) error {
+	return &undumpError{
+		pos: u.fset.Position(node.Pos()),
+		msg: fmt.Sprintf(format, a...),
+	}
+}
+
+// registerType registers typ and the types referred from typ.
+func (u *undumper) registerType(typ reflect.Type) {
+	d := &dumper{}
+	key := typeKey(d.typeString(typ))
+	if _, ok := u.types[key]; ok {
+		return
+	}
+	u.types[key] = typ
+	if pkgPath := typ.PkgPath(); pkgPath != "" {
+		// the type may be written without the package name by WithPackagePath.
+		d.packagePath = pkgPath
+		if local := typeKey(d.typeString(typ)); u.types[local] == nil {
+			u.types[local] = typ
+		}
+	}
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Chan:
+		u.registerType(typ.Elem())
+	case reflect.Map:
+		u.registerType(typ.Key())
+		u.registerType(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			u.registerType(typ.Field(i).Type)
+		}
+	case reflect.Func:
+		for i := 0; i < typ.NumIn(); i++ {
+			u.registerType(typ.In(i))
+		}
+		for i := 0; i < typ.NumOut(); i++ {
+			u.registerType(typ.Out(i))
+		}
+	}
+}
+
+func typeKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+var (
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+	errorType          = reflect.TypeOf((*error)(nil)).Elem()
+	unsafePointerType  = reflect.TypeOf(unsafe.Pointer(nil))
+	float32Type        = reflect.TypeOf(float32(0))
+)
+
+// predeclaredTypes is the predeclared types which can be written by dd.
+var predeclaredTypes = map[string]reflect.Type{
+	"bool":           boolType,
+	"string":         stringType,
+	"int":            intType,
+	"int8":           reflect.TypeOf(int8(0)),
+	"int16":          reflect.TypeOf(int16(0)),
+	"int32":          reflect.TypeOf(int32(0)),
+	"rune":           reflect.TypeOf(rune(0)),
+	"int64":          reflect.TypeOf(int64(0)),
+	"uint":           reflect.TypeOf(uint(0)),
+	"uint8":          reflect.TypeOf(uint8(0)),
+	"byte":           reflect.TypeOf(byte(0)),
+	"uint16":         reflect.TypeOf(uint16(0)),
+	"uint32":         reflect.TypeOf(uint32(0)),
+	"uint64":         reflect.TypeOf(uint64(0)),
+	"uintptr":        reflect.TypeOf(uintptr(0)),
+	"float32":        reflect.TypeOf(float32(0)),
+	"float64":        float64Type,
+	"complex64":      complex64Type,
+	"complex128":     complex128Type,
+	"error":          errorType,
+	"any":            emptyInterfaceType,
+	"unsafe.Pointer": unsafePointerType,
+}
+
+// resolveType returns the type of the type expression.
+// It reports false if expr is not a type.
+func (u *undumper) resolveType(expr ast.Expr) (reflect.Type, bool, error) {
+	if typ, ok := u.types[typeKey(u.src[expr.Pos()-1:expr.End()-1])]; ok {
+		return typ, true, nil
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		typ, ok := predeclaredTypes[expr.Name]
+		return typ, ok, nil
+	case *ast.SelectorExpr:
+		if x, ok := expr.X.(*ast.Ident); ok && x.Name+"."+expr.Sel.Name == "unsafe.Pointer" {
+			return unsafePointerType, true, nil
+		}
+		return nil, false, nil
+	case *ast.ParenExpr:
+		return u.resolveType(expr.X)
+	case *ast.StarExpr:
+		elem, err := u.mustResolveType(expr.X)
+		if err != nil {
+			return nil, true, err
+		}
+		return reflect.PtrTo(elem), true, nil
+	case *ast.ArrayType:
+		elem, err := u.mustResolveType(expr.Elt)
+		if err != nil {
+			return nil, true, err
+		}
+		if expr.Len == nil {
+			return reflect.SliceOf(elem), true, nil
+		}
+		n, ok := constValue(expr.Len)
+		if !ok {
+			return nil, true, u.errorf(expr.Len, "invalid array length")
+		}
+		length, ok := constant.Int64Val(n)
+		if !ok || length < 0 {
+			return nil, true, u.errorf(expr.Len, "invalid array length")
+		}
+		if length > maxLiteralIndex {
+			return nil, true, u.errorf(expr.Len, "array length %d is too large", length)
+		}
+		return reflect.ArrayOf(int(length), elem), true, nil
+	case *ast.MapType:
+		key, err := u.mustResolveType(expr.Key)
+		if err != nil {
+			return nil, true, err
+		}
+		elem, err := u.mustResolveType(expr.Value)
+		if err != nil {
+			return nil, true, err
+		}
+		if !key.Comparable() {
+			return nil, true, u.errorf(expr.Key, "invalid map key type %s", key)
+		}
+		return reflect.MapOf(key, elem), true, nil
+	case *ast.ChanType:
+		elem, err := u.mustResolveType(expr.Value)
+		if err != nil {
+			return nil, true, err
+		}
+		dir := reflect.BothDir
+		switch expr.Dir {
+		case ast.SEND:
+			dir = reflect.SendDir
+		case ast.RECV:
+			dir = reflect.RecvDir
+		}
+		return reflect.ChanOf(dir, elem), true, nil
+	case *ast.InterfaceType:
+		if len(expr.Methods.List) == 0 {
+			return emptyInterfaceType, true, nil
+		}
+	case *ast.FuncType, *ast.StructType:
+	default:
+		return nil, false, nil
+	}
+	return nil, true, u.errorf(expr, "unknown type %s: register it to the registry", u.src[expr.Pos()-1:expr.End()-1])
+}
+
+func (u *undumper) mustResolveType(expr ast.Expr) (reflect.Type, error) {
+	typ, ok, err := u.resolveType(expr)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, u.errorf(expr, "unknown type %s: register it to the registry", u.src[expr.Pos()-1:expr.End()-1])
+	}
+	return typ, nil
+}
+
+// constValue returns the value of the untyped constant expression.
+func constValue(expr ast.Expr) (constant.Value, bool) {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		v := constant.MakeFromLiteral(expr.Value, expr.Kind, 0)
+		return v, v.Kind() != constant.Unknown
+	case *ast.Ident:
+		switch expr.Name {
+		case "true":
+			return constant.MakeBool(true), true
+		case "false":
+			return constant.MakeBool(false), true
+		}
+	case *ast.ParenExpr:
+		return constValue(expr.X)
+	case *ast.UnaryExpr:
+		if expr.Op != token.SUB && expr.Op != token.ADD {
+			return nil, false
+		}
+		// the operator is not defined on the other constants. e.g. -"a"
+		if x, ok := constValue(expr.X); ok && isNumericConstant(x) {
+			return constant.UnaryOp(expr.Op, x, 0), true
+		}
+	}
+	return nil, false
+}
+
+func isNumericConstant(c constant.Value) bool {
+	switch c.Kind() {
+	case constant.Int, constant.Float, constant.Complex:
+		return true
+	}
+	return false
+}
+
+// eval evaluates expr as the value of typ.
+// If typ is nil or interface, the value has the default type.
+func (u *undumper) eval(expr ast.Expr, typ reflect.Type) (reflect.Value, error) {
+	if c, ok := constValue(expr); ok {
+		return u.constant(expr, c, typ)
+	}
+	var (
+		v   reflect.Value
+		err error
+	)
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return u.eval(expr.X, typ)
+	case *ast.Ident:
+		if expr.Name == "nil" {
+			if typ == nil {
+				return reflect.Value{}, u.errorf(expr, "untyped nil")
+			}
+			return reflect.Zero(typ), nil
+		}
+		v, err = u.lookup(expr)
+	case *ast.CompositeLit:
+		v, err = u.evalCompositeLit(expr, typ)
+	case *ast.UnaryExpr:
+		if expr.Op == token.SUB || expr.Op == token.ADD {
+			return reflect.Value{}, u.errorf(expr, "operator %s is not defined on the operand", expr.Op)
+		}
+		if expr.Op != token.AND {
+			return reflect.Value{}, u.errorf(expr, "unsupported operator %s", expr.Op)
+		}
+		v, err = u.evalAddr(expr.X, typ)
+	case *ast.CallExpr:
+		v, err = u.evalCall(expr, typ)
+	case *ast.FuncLit:
+		return reflect.Value{}, u.errorf(expr, "cannot undump the function")
+	default:
+		return reflect.Value{}, u.errorf(expr, "unsupported expression")
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return u.convert(expr, v, typ)
+}
+
+// convert converts v to typ.
+func (u *undumper) convert(expr ast.Expr, v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	if typ == nil || v.Type() == typ {
+		return v, nil
+	}
+	if v.Type().AssignableTo(typ) {
+		ret := reflect.New(typ).Elem()
+		ret.Set(v)
+		return ret, nil
+	}
+	if v.Type().ConvertibleTo(typ) && typ.Kind() != reflect.Interface {
+		// the conversion from the slice to the array (or the pointer to it)
+		// panics if the slice is shorter than the array.
+		if v.Kind() == reflect.Slice {
+			arrayType := typ
+			if typ.Kind() == reflect.Ptr {
+				arrayType = typ.Elem()
+			}
+			if arrayType.Kind() == reflect.Array && v.Len() < arrayType.Len() {
+				return reflect.Value{}, u.errorf(expr, "cannot convert slice with length %d to %s", v.Len(), typ)
+			}
+		}
+		return v.Convert(typ), nil
+	}
+	return reflect.Value{}, u.errorf(expr, "cannot use %s as %s", v.Type(), typ)
+}
+
+// constant returns the value of the untyped constant as typ.
+func (u *undumper) constant(expr ast.Expr, c constant.Value, typ reflect.Type) (reflect.Value, error) {
+	if typ == nil || typ.Kind() == reflect.Interface {
+		v, err := u.constant(expr, c, defaultType(c))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return u.convert(expr, v, typ)
+	}
+	v := reflect.New(typ).Elem()
+	kind := typ.Kind()
+	switch {
+	case kind == reflect.Bool && c.Kind() == constant.Bool:
+		v.SetBool(constant.BoolVal(c))
+		return v, nil
+	case kind == reflect.String && c.Kind() == constant.String:
+		v.SetString(constant.StringVal(c))
+		return v, nil
+	case isInt(kind):
+		if n, ok := constant.Int64Val(constant.ToInt(c)); ok && !v.OverflowInt(n) {
+			v.SetInt(n)
+			return v, nil
+		}
+	case isUint(kind):
+		if n, ok := constant.Uint64Val(constant.ToInt(c)); ok && !v.OverflowUint(n) {
+			v.SetUint(n)
+			return v, nil
+		}
+	case isFloat(kind):
+		if f := constant.ToFloat(c); f.Kind() == constant.Float || f.Kind() == constant.Int {
+			n, _ := constant.Float64Val(f)
+			if !v.OverflowFloat(n) {
+				v.SetFloat(n)
+				return v, nil
+			}
+		}
+	case isComplex(kind):
+		if cv := constant.ToComplex(c); cv.Kind() == constant.Complex {
+			r, _ := constant.Float64Val(constant.Real(cv))
+			i, _ := constant.Float64Val(constant.Imag(cv))
+			n := complex(r, i)
+			if !v.OverflowComplex(n) {
+				v.SetComplex(n)
+				return v, nil
+			}
+		}
+	}
+	return reflect.Value{}, u.errorf(expr, "cannot use %s as %s", c, typ)
+}
+
+func defaultType(c constant.Value) reflect.Type {
+	switch c.Kind() {
+	case constant.Bool:
+		return boolType
+	case constant.String:
+		return stringType
+	case constant.Int:
+		return intType
+	case constant.Float:
+		return float64Type
+	}
+	return complex128Type
+}
+
+// evalCompositeLit evaluates the composite literal. If the type is elided,
+// typ is used as the type of the literal.
+func (u *undumper) evalCompositeLit(lit *ast.CompositeLit, typ reflect.Type) (reflect.Value, error) {
+	litType := typ
+	if lit.Type != nil {
+		t, err := u.mustResolveType(lit.Type)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		litType = t
+	} else if typ != nil && typ.Kind() == reflect.Ptr {
+		// &T is elided. e.g. []*T{{...}}
+		v, err := u.evalCompositeLit(lit, typ.Elem())
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(v)
+		return ptr, nil
+	}
+	if litType == nil {
+		return reflect.Value{}, u.errorf(lit, "missing type in composite literal")
+	}
+
+	v := reflect.New(litType).Elem()
+	switch litType.Kind() {
+	case reflect.Struct:
+		for i, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				if i >= litType.NumField() {
+					return reflect.Value{}, u.errorf(elt, "too many values in struct literal")
+				}
+				if err := u.setField(v, i, elt); err != nil {
+					return reflect.Value{}, err
+				}
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				return reflect.Value{}, u.errorf(kv.Key, "invalid field name")
+			}
+			field, ok := litType.FieldByName(key.Name)
+			if !ok || len(field.Index) != 1 {
+				return reflect.Value{}, u.errorf(kv.Key, "unknown field %s in %s", key.Name, litType)
+			}
+			if err := u.setField(v, field.Index[0], kv.Value); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		index := 0
+		var elems []reflect.Value
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				n, err := u.eval(kv.Key, intType)
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				index, elt = int(n.Int()), kv.Value
+				switch {
+				case index < 0:
+					return reflect.Value{}, u.errorf(kv.Key, "index %d must be non-negative", index)
+				case litType.Kind() == reflect.Array && index >= litType.Len():
+					return reflect.Value{}, u.errorf(kv.Key, "index %d out of bounds [0:%d]", index, litType.Len())
+				case index >= maxLiteralIndex:
+					return reflect.Value{}, u.errorf(kv.Key, "index %d is too large", index)
+				}
+			}
+			elem, err := u.eval(elt, litType.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			for len(elems) <= index {
+				elems = append(elems, reflect.Zero(litType.Elem()))
+			}
+			elems[index] = elem
+			index++
+		}
+		if litType.Kind() == reflect.Slice {
+			v.Set(reflect.MakeSlice(litType, len(elems), len(elems)))
+		} else if len(elems) > litType.Len() {
+			return reflect.Value{}, u.errorf(lit, "too many values in %s", litType)
+		}
+		for i, elem := range elems {
+			v.Index(i).Set(elem)
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMapWithSize(litType, len(lit.Elts)))
+		for _, elt := range lit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return reflect.Value{}, u.errorf(elt, "missing key in map literal")
+			}
+			key, err := u.eval(kv.Key, litType.Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem, err := u.eval(kv.Value, litType.Elem())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	default:
+		return reflect.Value{}, u.errorf(lit, "invalid composite literal type %s", litType)
+	}
+	return v, nil
+}
+
+func (u *undumper) setField(v reflect.Value, i int, expr ast.Expr) error {
+	field := fieldOf(v, i)
+	elem, err := u.eval(expr, field.Type())
+	if err != nil {
+		return err
+	}
+	field.Set(elem)
+	return nil
+}
+
+// fieldOf returns the i'th field of the addressable struct.
+// The unexported field can be also set.
+func fieldOf(v reflect.Value, i int) reflect.Value {
+	field := v.Field(i)
+	if !isExported(v.Type().Field(i)) {
+		field = getUnexportedField(field)
+	}
+	return field
+}
+
+// evalAddr evaluates &expr.
+func (u *undumper) evalAddr(expr ast.Expr, typ reflect.Type) (reflect.Value, error) {
+	if lit, ok := expr.(*ast.CompositeLit); ok {
+		var elemType reflect.Type
+		if typ != nil && typ.Kind() == reflect.Ptr {
+			elemType = typ.Elem()
+		}
+		v, err := u.evalCompositeLit(lit, elemType)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		return ptr, nil
+	}
+	v, err := u.lvalue(expr)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if !v.CanAddr() {
+		return reflect.Value{}, u.errorf(expr, "cannot take the address")
+	}
+	return v.Addr(), nil
+}
+
+// evalCall evaluates the function call or the conversion.
+func (u *undumper) evalCall(call *ast.CallExpr, typ reflect.Type) (reflect.Value, error) {
+	switch fun := call.Fun.(type) {
+	case *ast.FuncLit:
+		if len(call.Args) > 0 {
+			return reflect.Value{}, u.errorf(call, "unsupported function call")
+		}
+		return u.invoke(fun)
+	case *ast.Ident:
+		switch fun.Name {
+		case "ptr":
+			// ptr(v) of HelperPointer
+			if len(call.Args) != 1 {
+				return reflect.Value{}, u.errorf(call, "wrong number of arguments")
+			}
+			var elemType reflect.Type
+			if typ != nil && typ.Kind() == reflect.Ptr {
+				elemType = typ.Elem()
+			}
+			return u.newPointer(call.Args[0], elemType)
+		case "complex":
+			return u.evalComplex(call, typ)
+		}
+	case *ast.IndexExpr:
+		// ptr[T](v) of HelperPointer
+		if ident, ok := fun.X.(*ast.Ident); ok && ident.Name == "ptr" {
+			elemType, err := u.mustResolveType(fun.Index)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if len(call.Args) != 1 {
+				return reflect.Value{}, u.errorf(call, "wrong number of arguments")
+			}
+			return u.newPointer(call.Args[0], elemType)
+		}
+	case *ast.SelectorExpr:
+		if pkg, ok := fun.X.(*ast.Ident); ok && pkg.Name == "math" {
+			return u.evalMath(call, fun.Sel.Name)
+		}
+	}
+
+	// conversion. e.g. int64(1), (*T)(nil)
+	convType, ok, err := u.resolveType(call.Fun)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if !ok {
+		switch call.Fun.(type) {
+		case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr:
+			return reflect.Value{}, u.errorf(call.Fun, "unknown type %s: register it to the registry", u.src[call.Fun.Pos()-1:call.Fun.End()-1])
+		}
+		return reflect.Value{}, u.errorf(call.Fun, "unsupported function call")
+	}
+	if len(call.Args) != 1 {
+		return reflect.Value{}, u.errorf(call, "wrong number of arguments in conversion to %s", convType)
+	}
+	arg := call.Args[0]
+	if inner, ok := arg.(*ast.CallExpr); ok && convType.Kind() != reflect.UnsafePointer {
+		if argType, ok, _ := u.resolveType(inner.Fun); ok && argType == unsafePointerType {
+			return reflect.Value{}, u.errorf(call, "cannot undump the address of %s", convType)
+		}
+	}
+	if convType == unsafePointerType {
+		// only nil can be undumped. e.g. unsafe.Pointer(uintptr(0))
+		v, err := u.eval(arg, nil)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if !isUint(v.Kind()) || v.Uint() != 0 {
+			return reflect.Value{}, u.errorf(call, "cannot undump the address of unsafe.Pointer")
+		}
+		return reflect.Zero(unsafePointerType), nil
+	}
+	v, err := u.eval(arg, convType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return u.convert(call, v, convType)
+}
+
+func (u *undumper) newPointer(expr ast.Expr, elemType reflect.Type) (reflect.Value, error) {
+	v, err := u.eval(expr, elemType)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	ptr := reflect.New(v.Type())
+	if elemType != nil {
+		ptr = reflect.New(elemType)
+	}
+	ptr.Elem().Set(v)
+	return ptr, nil
+}
+
+func (u *undumper) evalComplex(call *ast.CallExpr, typ reflect.Type) (reflect.Value, error) {
+	if len(call.Args) != 2 {
+		return reflect.Value{}, u.errorf(call, "wrong number of arguments")
+	}
+	var args [2]reflect.Value
+	partType := float64Type
+	switch {
+	case typ != nil && typ.Kind() == reflect.Complex64:
+		partType = float32Type
+	case typ == nil || typ.Kind() != reflect.Complex128:
+		// the result is complex64 if either argument is float32
+		// like complex(float32(math.Inf(1)), 0.0). The untyped constants
+		// are evaluated after the type is decided.
+		for i, arg := range call.Args {
+			if _, ok := constValue(arg); ok {
+				continue
+			}
+			v, err := u.eval(arg, nil)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if v.Kind() == reflect.Float32 {
+				partType = float32Type
+			}
+			args[i] = v
+		}
+	}
+	var parts [2]float64
+	for i, arg := range call.Args {
+		v := args[i]
+		if !v.IsValid() {
+			var err error
+			if v, err = u.eval(arg, partType); err != nil {
+				return reflect.Value{}, err
+			}
+		}
+		if !isFloat(v.Kind()) {
+			return reflect.Value{}, u.errorf(arg, "cannot use %s as float", v.Type())
+		}
+		parts[i] = v.Float()
+	}
+	v := reflect.New(complex128Type).Elem()
+	if partType.Kind() == reflect.Float32 {
+		v = reflect.New(complex64Type).Elem()
+	}
+	v.SetComplex(complex(parts[0], parts[1]))
+	return v, nil
+}
+
+// evalMath evaluates the functions of math package which are used to
+// write the special floating-point values.
+func (u *undumper) evalMath(call *ast.CallExpr, name string) (reflect.Value, error) {
+	args := make([]float64, len(call.Args))
+	for i, arg := range call.Args {
+		v, err := u.eval(arg, float64Type)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		args[i] = v.Float()
+	}
+	var f float64
+	switch {
+	case name == "NaN" && len(args) == 0:
+		f = math.NaN()
+	case name == "Inf" && len(args) == 1:
+		f = math.Inf(int(args[0]))
+	case name == "Copysign" && len(args) == 2:
+		f = math.Copysign(args[0], args[1])
+	default:
+		return reflect.Value{}, u.errorf(call, "unsupported function math.%s", name)
+	}
+	return reflect.ValueOf(f), nil
+}
+
+// invoke runs the body of the immediately invoked function.
+// e.g. func() *int { v := 1; return &v }()
+func (u *undumper) invoke(fn *ast.FuncLit) (reflect.Value, error) {
+	u.scopes = append(u.scopes, make(map[string]reflect.Value))
+	defer func() {
+		u.scopes = u.scopes[:len(u.scopes)-1]
+	}()
+	for _, stmt := range fn.Body.List {
+		switch stmt := stmt.(type) {
+		case *ast.AssignStmt:
+			if len(stmt.Lhs) != 1 || len(stmt.Rhs) != 1 {
+				return reflect.Value{}, u.errorf(stmt, "unsupported assignment")
+			}
+			if stmt.Tok == token.DEFINE {
+				if err := u.declare(stmt.Lhs[0], nil, stmt.Rhs[0]); err != nil {
+					return reflect.Value{}, err
+				}
+				continue
+			}
+			if err := u.assign(stmt.Lhs[0], stmt.Rhs[0]); err != nil {
+				return reflect.Value{}, err
+			}
+		case *ast.DeclStmt:
+			// var v T = x
+			decl, ok := stmt.Decl.(*ast.GenDecl)
+			if !ok || decl.Tok != token.VAR || len(decl.Specs) != 1 {
+				return reflect.Value{}, u.errorf(stmt, "unsupported declaration")
+			}
+			spec := decl.Specs[0].(*ast.ValueSpec)
+			if len(spec.Names) != 1 || len(spec.Values) != 1 || spec.Type == nil {
+				return reflect.Value{}, u.errorf(stmt, "unsupported declaration")
+			}
+			if err := u.declare(spec.Names[0], spec.Type, spec.Values[0]); err != nil {
+				return reflect.Value{}, err
+			}
+		case *ast.ReturnStmt:
+			if len(stmt.Results) != 1 {
+				return reflect.Value{}, u.errorf(stmt, "unsupported return statement")
+			}
+			return u.eval(stmt.Results[0], nil)
+		default:
+			return reflect.Value{}, u.errorf(stmt, "unsupported statement")
+		}
+	}
+	return reflect.Value{}, u.errorf(fn, "missing return")
+}
+
+// declare declares the variable in the current scope.
+func (u *undumper) declare(lhs ast.Expr, typExpr ast.Expr, rhs ast.Expr) error {
+	ident, ok := lhs.(*ast.Ident)
+	if !ok {
+		return u.errorf(lhs, "non-name on left side of :=")
+	}
+	var typ reflect.Type
+	if typExpr != nil {
+		t, err := u.mustResolveType(typExpr)
+		if err != nil {
+			return err
+		}
+		typ = t
+	}
+	v, err := u.eval(rhs, typ)
+	if err != nil {
+		return err
+	}
+	// the variable is addressable.
+	variable := reflect.New(v.Type()).Elem()
+	variable.Set(v)
+	u.scopes[len(u.scopes)-1][ident.Name] = variable
+	return nil
+}
+
+func (u *undumper) lookup(ident *ast.Ident) (reflect.Value, error) {
+	for i := len(u.scopes) - 1; i >= 0; i-- {
+		if v, ok := u.scopes[i][ident.Name]; ok {
+			return v, nil
+		}
+	}
+	return reflect.Value{}, u.errorf(ident, "undefined: %s", ident.Name)
+}
+
+// assign evaluates the assignment statement like "v1.Next = v2".
+func (u *undumper) assign(lhs, rhs ast.Expr) error {
+	if index, ok := lhs.(*ast.IndexExpr); ok {
+		m, err := u.lvalue(index.X)
+		if err != nil {
+			return err
+		}
+		if m.Kind() == reflect.Map {
+			key, err := u.eval(index.Index, m.Type().Key())
+			if err != nil {
+				return err
+			}
+			v, err := u.eval(rhs, m.Type().Elem())
+			if err != nil {
+				return err
+			}
+			if m.IsNil() {
+				return u.errorf(lhs, "assignment to entry in nil map")
+			}
+			m.SetMapIndex(key, v)
+			return nil
+		}
+	}
+	dst, err := u.lvalue(lhs)
+	if err != nil {
+		return err
+	}
+	if !dst.CanSet() {
+		return u.errorf(lhs, "cannot assign")
+	}
+	v, err := u.eval(rhs, dst.Type())
+	if err != nil {
+		return err
+	}
+	dst.Set(v)
+	return nil
+}
+
+// lvalue evaluates the operand of the assignment or the address operation.
+func (u *undumper) lvalue(expr ast.Expr) (reflect.Value, error) {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return u.lookup(expr)
+	case *ast.ParenExpr:
+		return u.lvalue(expr.X)
+	case *ast.StarExpr:
+		ptr, err := u.lvalue(expr.X)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+			return reflect.Value{}, u.errorf(expr, "invalid indirect")
+		}
+		return ptr.Elem(), nil
+	case *ast.SelectorExpr:
+		v, err := u.lvalue(expr.X)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if v.Kind() == reflect.Ptr && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return reflect.Value{}, u.errorf(expr, "%s has no field %s", v.Type(), expr.Sel.Name)
+		}
+		field, ok := v.Type().FieldByName(expr.Sel.Name)
+		if !ok || len(field.Index) != 1 || !v.CanAddr() {
+			return reflect.Value{}, u.errorf(expr, "%s has no field %s", v.Type(), expr.Sel.Name)
+		}
+		return fieldOf(v, field.Index[0]), nil
+	case *ast.IndexExpr:
+		v, err := u.lvalue(expr.X)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Array {
+			v = v.Elem()
+		}
+		switch v.Kind() {
+		case reflect.Map:
+			key, err := u.eval(expr.Index, v.Type().Key())
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			elem := v.MapIndex(key)
+			if !elem.IsValid() {
+				return reflect.Zero(v.Type().Elem()), nil
+			}
+			return elem, nil
+		case reflect.Slice, reflect.Array:
+			i, err := u.eval(expr.Index, intType)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			if i.Int() < 0 || i.Int() >= int64(v.Len()) {
+				return reflect.Value{}, u.errorf(expr, "index out of range")
+			}
+			return v.Index(int(i.Int())), nil
+		}
+		return reflect.Value{}, u.errorf(expr, "cannot index %s", v.Type())
+	case *ast.TypeAssertExpr:
+		v, err := u.lvalue(expr.X)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		typ, err := u.mustResolveType(expr.Type)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		if v.Kind() != reflect.Interface || v.IsNil() || v.Elem().Type() != typ {
+			return reflect.Value{}, u.errorf(expr, "invalid type assertion")
+		}
+		return v.Elem(), nil
+	}
+	return reflect.Value{}, u.errorf(expr, "unsupported expression")
+}
@@ -0,0 +1,236 @@
+package dd_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Code-Hex/dd"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+type undumpItem struct {
+	Name   string
+	price  float64
+	Tags   []string
+	Attrs  map[string]interface{}
+	Parent *undumpItem
+	Count  *int
+	Any    interface{}
+	Matrix [2][2]complex64
+	Wait   time.Duration
+}
+
+type undumpStatus int
+
+func TestUndump(t *testing.T) {
+	count := 3
+	var any interface{} = undumpStatus(2)
+	cases := []struct {
+		name     string
+		v        interface{}
+		registry []interface{}
+		opts     []dd.OptionFunc
+	}{
+		{
+			name: "primitives",
+			v:    []interface{}{1, int8(-2), uint64(math.MaxUint64), 1.5, float32(2), "str", true, 'a', complex(1, -2), uintptr(0x10)},
+		},
+		{
+			name: "special floats",
+			v:    []interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1)), math.Copysign(0, -1), complex(math.Inf(1), 1), complex64(complex(math.Inf(1), 0))},
+		},
+		{
+			name: "struct",
+			v: &undumpItem{
+				Name:   "book",
+				price:  9.5,
+				Tags:   []string{"a", "b"},
+				Attrs:  map[string]interface{}{"status": undumpStatus(1), "nil": nil, "list": []interface{}{1.0, "x"}},
+				Parent: &undumpItem{Name: "parent"},
+				Count:  &count,
+				Any:    &any,
+				Matrix: [2][2]complex64{{complex(1, 2)}, {complex(float32(math.Inf(1)), 0)}},
+				Wait:   time.Second,
+			},
+			registry: []interface{}{undumpStatus(0), (*interface{})(nil)},
+		},
+		{
+			name:     "helper pointer",
+			v:        []interface{}{&count, &any, map[string]**int{"k": func() **int { p := &count; return &p }()}},
+			registry: []interface{}{(*int)(nil), (*interface{})(nil), map[string]**int(nil), undumpStatus(0)},
+			opts:     []dd.OptionFunc{dd.WithPointerFormat(dd.HelperPointer)},
+		},
+		{
+			name: "uint formats",
+			v:    []uint16{1, 0xff, 0xabc},
+			opts: []dd.OptionFunc{dd.WithUintFormat(dd.BinaryUint)},
+		},
+		{
+			name: "nil values",
+			v: map[string]interface{}{
+				"slice": []int(nil),
+				"map":   map[string]int(nil),
+				"ptr":   (*undumpItem)(nil),
+				"func":  (func())(nil),
+			},
+			registry: []interface{}{[]int(nil), map[string]int(nil), (*undumpItem)(nil), (func())(nil)},
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			src := dd.Dump(tc.v, tc.opts...)
+			got := reflect.New(reflect.TypeOf(tc.v))
+			if err := dd.Undump(src, got.Interface(), tc.registry...); err != nil {
+				t.Fatalf("%v\n%s", err, src)
+			}
+			if diff := cmp.Diff(tc.v, got.Elem().Interface(), cmpopts.EquateNaNs(), cmp.AllowUnexported(undumpItem{}), cmpopts.EquateEmpty()); diff != "" {
+				t.Fatalf("(-want, +got)\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUndumpGraph(t *testing.T) {
+	a := &listNode{Val: 1}
+	b := &listNode{Val: 2, Prev: a}
+	a.Next = b
+	b.Next = a
+	src := dd.Dump(a, dd.WithGraph())
+
+	var got *listNode
+	if err := dd.Undump(src, &got); err != nil {
+		t.Fatalf("%v\n%s", err, src)
+	}
+	if got.Val != 1 || got.Next.Val != 2 {
+		t.Fatalf("unexpected values: %s", dd.Dump(got, dd.WithGraph()))
+	}
+	if got.Next.Next != got || got.Next.Prev != got {
+		t.Fatalf("the references are not restored: %s", dd.Dump(got, dd.WithGraph()))
+	}
+}
+
+func TestUndumpError(t *testing.T) {
+	cases := []struct {
+		name   string
+		src    string
+		target interface{}
+		want   string
+	}{
+		{
+			name:   "not pointer",
+			src:    "1",
+			target: 1,
+			want:   "target must be a non-nil pointer",
+		},
+		{
+			name:   "syntax error",
+			src:    "[]int{",
+			target: new([]int),
+			want:   "failed to parse",
+		},
+		{
+			name:   "unknown type",
+			src:    "[]interface{}{\n  dd_test.undumpStatus(1),\n}",
+			target: new([]interface{}),
+			want:   "2:3: unknown type dd_test.undumpStatus: register it to the registry",
+		},
+		{
+			name:   "overflow",
+			src:    "[]int8{128}",
+			target: new([]int8),
+			want:   "1:8: cannot use 128 as int8",
+		},
+		{
+			name:   "function",
+			src:    "func() {\n  // ...\n}",
+			target: new(func()),
+			want:   "1:1: cannot undump the function",
+		},
+		{
+			name:   "address",
+			src:    "(chan int)(unsafe.Pointer(uintptr(0xc000012345)))",
+			target: new(chan int),
+			want:   "1:1: cannot undump the address of chan int",
+		},
+		{
+			name:   "negative index",
+			src:    "[]int{-1: 1}",
+			target: new([]int),
+			want:   "1:7: index -1 must be non-negative",
+		},
+		{
+			name:   "huge index",
+			src:    "[]int{1099511627776: 1}",
+			target: new([]int),
+			want:   "1:7: index 1099511627776 is too large",
+		},
+		{
+			name:   "array index out of bounds",
+			src:    "[2]int{2: 1}",
+			target: new([2]int),
+			want:   "1:8: index 2 out of bounds [0:2]",
+		},
+		{
+			name:   "negative index in assignment",
+			src:    "func() *[]int {\n  v1 := &[]int{1}\n  (*v1)[-1] = 2\n  return v1\n}()",
+			target: new(*[]int),
+			want:   "3:3: index out of range",
+		},
+		{
+			name:   "negation of string",
+			src:    `-"a"`,
+			target: new(string),
+			want:   "1:1: operator - is not defined on the operand",
+		},
+		{
+			name:   "incomparable map key",
+			src:    "map[[]int]int{}",
+			target: new(interface{}),
+			want:   "1:5: invalid map key type []int",
+		},
+		{
+			name:   "negative array length",
+			src:    "[-1]int{}",
+			target: new(interface{}),
+			want:   "1:2: invalid array length",
+		},
+		{
+			name:   "huge array length",
+			src:    "[1099511627776]byte{}",
+			target: new(interface{}),
+			want:   "1:2: array length 1099511627776 is too large",
+		},
+		{
+			name:   "short slice to array pointer",
+			src:    "(*[4]int)([]int{1})",
+			target: new(*[4]int),
+			want:   "1:11: cannot convert slice with length 1 to *[4]int",
+		},
+		{
+			name:   "assignment to nil map",
+			src:    "func() map[string]int {\n  v1 := map[string]int(nil)\n  v1[\"a\"] = 1\n  return v1\n}()",
+			target: new(map[string]int),
+			want:   "3:3: assignment to entry in nil map",
+		},
+		{
+			name:   "type mismatch",
+			src:    `[]string{"a"}`,
+			target: new([]int),
+			want:   "1:1: cannot use []string as []int",
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := dd.Undump(tc.src, tc.target)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("want error %q, but got %v", tc.want, err)
+			}
+		})
+	}
+}
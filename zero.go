@@ -12,10 +12,10 @@ var zeroPrimitives = map[reflect.Type]string{
 	reflect.TypeOf(int16(0)):             "0",
 	reflect.TypeOf(int32(0)):             "0",
 	reflect.TypeOf(int64(0)):             "0",
-	reflect.TypeOf(float32(0)):           "0.000000",
-	reflect.TypeOf(float64(0)):           "0.000000",
-	reflect.TypeOf(complex64((0 + 0i))):  "(0+0i)",
-	reflect.TypeOf(complex128((0 + 0i))): "(0+0i)",
+	reflect.TypeOf(float32(0)):           "0.0",
+	reflect.TypeOf(float64(0)):           "0.0",
+	reflect.TypeOf(complex64((0 + 0i))):  "complex(0.0, 0.0)",
+	reflect.TypeOf(complex128((0 + 0i))): "complex(0.0, 0.0)",
 	reflect.TypeOf(string("")):           "\"\"",
 	reflect.TypeOf(int(0)):               "0",
 	reflect.TypeOf(uint(0x0)):            "0",